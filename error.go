@@ -1,7 +1,10 @@
 package httpserver
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 )
 
 type Error struct {
@@ -41,3 +44,110 @@ func (e Error) Error() string {
 func (e Error) Code() int {
 	return e.HttpCode
 }
+
+// RouteError describes a problem with the route which was added in to the router
+type RouteError struct {
+	Path string
+	Err  error
+}
+
+func (e *RouteError) Unwrap() error { return e.Err }
+
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("route [%s]: %s", e.Path, e.Err.Error())
+}
+
+// RouteErrors is a list of errors returned by Router.Validate
+type RouteErrors []error
+
+func (e RouteErrors) Error() string {
+	texts := make([]string, 0, len(e))
+	for _, err := range e {
+		texts = append(texts, err.Error())
+	}
+
+	return strings.Join(texts, "; ")
+}
+
+// Locations of the request values
+const (
+	LocationBody   = "body"
+	LocationQuery  = "query"
+	LocationHeader = "header"
+	LocationArgs   = "args"
+	LocationForm   = "form"
+)
+
+// Codes of the field errors
+const (
+	CodeInvalid   = "invalid"
+	CodeMalformed = "malformed"
+)
+
+// FieldError describes the request value which can not be bound to the field of the request object
+type FieldError struct {
+	Field    string `json:"field,omitempty"`
+	Location string `json:"location"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+
+	cause error
+}
+
+func (e *FieldError) Unwrap() error { return e.cause }
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Location, e.Message)
+	}
+
+	return fmt.Sprintf("%s [%s]: %s", e.Location, e.Field, e.Message)
+}
+
+// RequestError is returned with code 400 if the request can not be bound to the request object. It contains
+// errors of all fields which are incorrect and it is written to the response as:
+//
+//	{
+//		"code": 400,
+//		"error": "incorrect request",
+//		"errors": [
+//			{"field": "limit", "location": "query", "code": "invalid", "message": "value [a] must be int"}
+//		]
+//	}
+//
+// The field is omitted if the error is not related to any field, e.g. the body is malformed. Middlewares can get
+// it from the result of the handler by errors.As. The response format can be changed by Options.RequestErrorFormatter.
+type RequestError struct {
+	Errors []*FieldError
+}
+
+func (e *RequestError) Code() int { return http.StatusBadRequest }
+
+func (e *RequestError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e.Errors[0]
+}
+
+func (e *RequestError) Error() string {
+	texts := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		texts = append(texts, err.Error())
+	}
+
+	return fmt.Sprintf("http error [%d] incorrect request: %s", e.Code(), strings.Join(texts, "; "))
+}
+
+func (e *RequestError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		HttpCode  int           `json:"code"`
+		ErrorText string        `json:"error"`
+		Errors    []*FieldError `json:"errors"`
+	}{
+		HttpCode:  e.Code(),
+		ErrorText: "incorrect request",
+		Errors:    e.Errors,
+	})
+}
@@ -0,0 +1,119 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// defaultMaxMemory is the size of the multipart form which is stored in memory, other files are stored on disk
+const defaultMaxMemory = 32 << 20
+
+// MultipartLimits sets limits of the multipart/form-data request. Files which do not fit in maxMemory bytes are
+// stored in temporary files and the whole request body is limited by maxMemory+maxDisk bytes. The request body
+// is not limited if maxDisk is negative, it is by default. Temporary files are removed after the handler returns.
+func MultipartLimits(maxMemory, maxDisk int64) Option {
+	return func(d *apiDescription) {
+		d.maxMemory = maxMemory
+		d.maxDisk = maxDisk
+	}
+}
+
+// parseMultipart reads the multipart form of the request and returns a function which removes temporary files
+func parseMultipart(r *http.Request, maxMemory, maxDisk int64) (func(), error) {
+	if maxDisk >= 0 {
+		r.Body = http.MaxBytesReader(nil, r.Body, maxMemory+maxDisk)
+	}
+
+	err := r.ParseMultipartForm(maxMemory)
+
+	cleanup := func() {
+		if r.MultipartForm != nil {
+			_ = r.MultipartForm.RemoveAll()
+		}
+	}
+
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		cleanup()
+
+		return nil, NewError(http.StatusRequestEntityTooLarge, "request body is larger than %d bytes", maxErr.Limit)
+	}
+
+	if err != nil {
+		cleanup()
+
+		return nil, &RequestError{Errors: []*FieldError{{
+			Location: LocationForm,
+			Code:     CodeMalformed,
+			Message:  err.Error(),
+			cause:    err,
+		}}}
+	}
+
+	return cleanup, nil
+}
+
+// isFileType returns true if the uploaded file can be set to the field of the type
+func isFileType(t reflect.Type) bool {
+	switch t {
+	case reflect.TypeOf((*multipart.FileHeader)(nil)),
+		reflect.TypeOf([]*multipart.FileHeader(nil)),
+		reflect.TypeOf((*multipart.File)(nil)).Elem(),
+		reflect.TypeOf((*io.Reader)(nil)).Elem(),
+		reflect.TypeOf((*io.ReadCloser)(nil)).Elem():
+		return true
+	default:
+		return false
+	}
+}
+
+func parseForm(tagValue string, targetValue interface{}, values url.Values) error {
+	formValues := values[tagValue]
+	if len(formValues) == 0 || len(formValues) == 1 && formValues[0] == "" {
+		return nil
+	}
+
+	return setValues(targetValue, formValues)
+}
+
+// parseFile sets files of the multipart form to the field. Files which are opened for reading are added to
+// the closers and must be closed after the handler returns.
+func parseFile(tagValue string, targetValue interface{}, form *multipart.Form, closers *[]io.Closer) error {
+	if form == nil || len(form.File[tagValue]) == 0 {
+		return nil
+	}
+
+	files := form.File[tagValue]
+
+	switch v := targetValue.(type) {
+	case **multipart.FileHeader:
+		*v = files[0]
+	case *[]*multipart.FileHeader:
+		*v = files
+	case *multipart.File, *io.Reader, *io.ReadCloser:
+		f, err := files[0].Open()
+		if err != nil {
+			return err
+		}
+
+		*closers = append(*closers, f)
+
+		switch v := v.(type) {
+		case *multipart.File:
+			*v = f
+		case *io.Reader:
+			*v = f
+		case *io.ReadCloser:
+			*v = f
+		}
+	default:
+		return fmt.Errorf("type %T is not supported for files", targetValue)
+	}
+
+	return nil
+}
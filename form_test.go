@@ -0,0 +1,217 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type uploadRequest struct {
+	Name   string                  `form:"name" validate:"required"`
+	Count  int                     `form:"count"`
+	Avatar *multipart.FileHeader   `file:"avatar"`
+	Docs   []*multipart.FileHeader `file:"docs"`
+	Data   io.Reader               `file:"data"`
+}
+
+func multipartBody(t *testing.T, values map[string]string, files [][2]string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+
+	for k, v := range values {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, f := range files {
+		fw, err := mw.CreateFormFile(f[0], f[0]+".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _ = fw.Write([]byte(f[1]))
+	}
+
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return body, mw.FormDataContentType()
+}
+
+func TestMultipartForm(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	tmpFiles := func() int {
+		entries, err := os.ReadDir(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return len(entries)
+	}
+
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	var stored int
+
+	upload := func(ctx context.Context, c *TestContainer, u *TestUserData, r *uploadRequest) (*TestResponse, error) {
+		f, err := r.Avatar.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		defer f.Close()
+
+		stored = tmpFiles()
+
+		avatar, _ := io.ReadAll(f)
+		data, _ := io.ReadAll(r.Data)
+
+		return &TestResponse{Data: fmt.Sprintf("%s %d %s %d %s", r.Name, r.Count, avatar, len(r.Docs), data)}, nil
+	}
+
+	router.Add("/upload", handler{
+		Post: Create(upload, MultipartLimits(1, -1)),
+		Put:  Create(upload, MultipartLimits(16, 512)),
+	})
+
+	router.AddSwagger("/swagger.json", SwaggerOpt{})
+
+	h := NewHttpHandler(router, Options{})
+
+	do := func(method string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/upload", body)
+		r.Header.Set("Content-Type", contentType)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	body, contentType := multipartBody(t, map[string]string{"name": "bob", "count": "2"}, [][2]string{
+		{"avatar", "image"},
+		{"docs", "a"},
+		{"docs", "b"},
+		{"data", "stream"},
+	})
+
+	w := do(http.MethodPost, body, contentType)
+	assert(t, w.Code, http.StatusOK)
+	assert(t, w.Body.String(), "{\"data\":\"bob 2 image 2 stream\"}\n")
+
+	if stored == 0 {
+		t.Error("files are not stored on disk")
+	}
+
+	assert(t, tmpFiles(), 0)
+
+	body, contentType = multipartBody(t, map[string]string{"count": "a"}, nil)
+
+	w = do(http.MethodPost, body, contentType)
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"count","location":"form","code":"invalid","message":"value [a] must be int"},{"field":"name","location":"form","code":"required","message":"value is required"}]}`+"\n")
+
+	body, contentType = multipartBody(t, map[string]string{"name": "bob"}, [][2]string{{"avatar", strings.Repeat("a", 1024)}})
+
+	w = do(http.MethodPut, body, contentType)
+	assert(t, w.Code, http.StatusRequestEntityTooLarge)
+
+	w = do(http.MethodPost, strings.NewReader("--x\r\n"), "multipart/form-data; boundary=x")
+	assert(t, w.Code, http.StatusBadRequest)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	for _, s := range []string{
+		`"consumes":["multipart/form-data"]`,
+		`{"in":"formData","name":"name","type":"string","required":true}`,
+		`{"in":"formData","name":"count","type":"integer","format":"int64"}`,
+		`{"in":"formData","name":"avatar","type":"file"}`,
+		`{"in":"formData","name":"docs","type":"file"}`,
+	} {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("swagger does not contain %s", s)
+		}
+	}
+}
+
+func TestMultipartFileType(t *testing.T) {
+	defer func() {
+		assert(t, recover(), "incorrect tags of the request [badUpload]: field [badUpload.File]: type string is not supported for files")
+	}()
+
+	type badUpload struct {
+		File string `file:"file"`
+	}
+
+	Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *badUpload) (*TestResponse, error) {
+		return nil, nil
+	})
+}
+
+type loginRequest struct {
+	User     string   `form:"user" validate:"required"`
+	Remember bool     `form:"remember"`
+	Scopes   []string `form:"scope"`
+	Ports    []int    `form:"port"`
+}
+
+func TestURLEncodedForm(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/login", handler{
+		Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *loginRequest) (*TestResponse, error) {
+			return &TestResponse{Data: fmt.Sprintf("%s %v %v %v", r.User, r.Remember, r.Scopes, r.Ports)}, nil
+		}),
+	})
+
+	router.AddSwagger("/swagger.json", SwaggerOpt{})
+
+	h := NewHttpHandler(router, Options{})
+
+	do := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/login?user=query", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := do("user=bob&remember=1&scope=read&scope=write&port=80&port=443")
+	assert(t, w.Code, http.StatusOK)
+	assert(t, w.Body.String(), "{\"data\":\"bob true [read write] [80 443]\"}\n")
+
+	w = do("remember=yes&port=80&port=http")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"remember","location":"form","code":"invalid","message":"value [yes] must be bool"},{"field":"port","location":"form","code":"invalid","message":"value [http] must be int"},{"field":"user","location":"form","code":"required","message":"value is required"}]}`+"\n")
+
+	w = do("user=%zz")
+	assert(t, w.Code, http.StatusBadRequest)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	for _, s := range []string{
+		`"consumes":["application/x-www-form-urlencoded","multipart/form-data"]`,
+		`{"in":"formData","name":"user","type":"string","required":true}`,
+		`{"in":"formData","name":"remember","type":"boolean"}`,
+		`{"in":"formData","name":"scope","type":"array","items":{"type":"string"},"collectionFormat":"multi"}`,
+	} {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("swagger does not contain %s", s)
+		}
+	}
+}
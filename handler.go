@@ -4,16 +4,214 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
+type allowedMethodsKey struct{}
+
+// AllowedMethods returns methods supported by the matched route. It is available in the handler
+// which was set by Router.MethodNotAllowed.
+func AllowedMethods(ctx context.Context) []string {
+	methods, _ := ctx.Value(allowedMethodsKey{}).([]string)
+
+	return methods
+}
+
+// On returns a copy of the handler with the method handler for any HTTP method.
+// Handlers for standard methods are set to the corresponding fields.
+func (h Handler[C, A]) On(method string, mh *MethodHandler[C, A]) Handler[C, A] {
+	switch method {
+	case http.MethodGet:
+		h.Get = mh
+	case http.MethodHead:
+		h.Head = mh
+	case http.MethodPost:
+		h.Post = mh
+	case http.MethodPut:
+		h.Put = mh
+	case http.MethodPatch:
+		h.Patch = mh
+	case http.MethodDelete:
+		h.Delete = mh
+	case http.MethodOptions:
+		h.Options = mh
+	default:
+		methods := make(map[string]*MethodHandler[C, A], len(h.Methods)+1)
+		for m, v := range h.Methods {
+			methods[m] = v
+		}
+
+		methods[method] = mh
+		h.Methods = methods
+	}
+
+	return h
+}
+
+// normalizeMethods returns a copy of the handler where handlers of standard methods are moved from Methods
+// to the corresponding fields and names of other methods are in upper case
+func (h Handler[C, A]) normalizeMethods() (Handler[C, A], error) {
+	names := make([]string, 0, len(h.Methods))
+	for m := range h.Methods {
+		names = append(names, m)
+	}
+
+	sort.Strings(names)
+
+	methods := h.Methods
+	h.Methods = nil
+
+	for _, m := range names {
+		name := strings.ToUpper(m)
+
+		prev := h.method(name)
+		if name == http.MethodHead {
+			prev = h.Head
+		}
+
+		if prev != nil {
+			return h, fmt.Errorf("handler for method [%s] is set twice", name)
+		}
+
+		h = h.On(name, methods[m])
+	}
+
+	return h, nil
+}
+
+func (h *Handler[C, A]) method(method string) *MethodHandler[C, A] {
+	switch method {
+	case http.MethodGet:
+		return h.Get
+	case http.MethodHead:
+		if h.Head != nil {
+			return h.Head
+		}
+
+		return h.Get
+	case http.MethodOptions:
+		return h.Options
+	case http.MethodPost:
+		return h.Post
+	case http.MethodPut:
+		return h.Put
+	case http.MethodPatch:
+		return h.Patch
+	case http.MethodDelete:
+		return h.Delete
+	default:
+		return h.Methods[method]
+	}
+}
+
+// handlers returns all method handlers which were set explicitly
+func (h *Handler[C, A]) handlers() OrderedMap[*MethodHandler[C, A]] {
+	var res OrderedMap[*MethodHandler[C, A]]
+
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions} {
+		if mh := h.method(m); mh != nil && (m != http.MethodHead || h.Head != nil) {
+			res.Add(m, mh)
+		}
+	}
+
+	methods := make([]string, 0, len(h.Methods))
+	for m := range h.Methods {
+		methods = append(methods, m)
+	}
+
+	sort.Strings(methods)
+
+	for _, m := range methods {
+		if mh := h.Methods[m]; mh != nil {
+			res.Add(m, mh)
+		}
+	}
+
+	return res
+}
+
+func (h *Handler[C, A]) allowedMethods() []string {
+	methods := make([]string, 0, 7)
+
+	for _, m := range h.handlers() {
+		if m.name == http.MethodOptions {
+			continue
+		}
+
+		methods = append(methods, m.name)
+
+		if m.name == http.MethodGet && h.Head == nil {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+
+	// OPTIONS request is always handled automatically if the handler does not have own
+	return append(methods, http.MethodOptions)
+}
+
 func handleHttpRequest[C, A any](ctx context.Context, router Router[C, A], c C, af AuthFunc[A], w http.ResponseWriter, r *http.Request) (interface{}, bool) {
-	ep, argsPlace, args := router.get(r.URL.Path)
-	if ep == nil {
+	var (
+		ep        *Handler[C, A]
+		argsPlace []string
+	)
+
+	rt, args, p := router.match(r.Host, r.URL.Path)
+
+	if rt != nil && p != r.URL.Path && router.pathOpt.RedirectCode != 0 {
+		u := url.URL{Path: p, RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, u.String(), router.pathOpt.RedirectCode)
+
+		return nil, false
+	}
+
+	if rt != nil && rt.handler.mount != nil {
+		// the last argument of the mounted route is the rest of the path
+		rest := ""
+		if len(args) > len(rt.args) {
+			rest = args[len(args)-1]
+		}
+
+		if rest == "" && rt.handler.mountDir && !strings.HasSuffix(r.URL.Path, "/") {
+			u := url.URL{Path: r.URL.Path + "/", RawQuery: r.URL.RawQuery}
+			http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+
+			return nil, false
+		}
+
+		r2 := r.WithContext(ctx)
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + rest
+		r2.URL.RawPath = ""
+
+		rt.handler.mount.ServeHTTP(w, r2)
+
+		return nil, false
+	}
+
+	var scope *SubRouter[C, A]
+
+	if rt != nil {
+		h, err := router.endpoint(rt, w, r)
+		if err != nil {
+			return err, true
+		}
+
+		ep, argsPlace, scope = h, rt.args, rt.scope
+	} else if dr := router.defaultFor(r.Host, p); dr != nil {
+		ep, scope = &dr.handler, dr.scope
+	} else if router.defaultRoute != nil {
+		ep = router.defaultRoute
+	} else {
 		return NewError(http.StatusNotFound, "method not exist"), true
 	}
 
@@ -24,31 +222,43 @@ func handleHttpRequest[C, A any](ctx context.Context, router Router[C, A], c C,
 		}
 	}
 
-	var handler *MethodHandler[C, A]
+	handler := ep.method(r.Method)
 
-	switch r.Method {
-	case http.MethodGet:
-		handler = ep.Get
-	case http.MethodPost:
-		handler = ep.Post
-	case http.MethodPut:
-		handler = ep.Put
-	case http.MethodPatch:
-		handler = ep.Patch
-	case http.MethodDelete:
-		handler = ep.Delete
+	if handler == nil && rt != nil && r.Method == http.MethodOptions {
+		w.Header().Set("Allow", strings.Join(ep.allowedMethods(), ", "))
+
+		return NoContent{}, true
 	}
 
-	if handler == nil {
+	if handler == nil && rt == nil {
 		return NewError(http.StatusNotFound, "method not supported"), true
 	}
 
+	if handler == nil {
+		allowed := ep.allowedMethods()
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+		if router.notAllowed == nil {
+			return NewError(http.StatusMethodNotAllowed, "method not allowed"), true
+		}
+
+		ctx = context.WithValue(ctx, allowedMethodsKey{}, allowed)
+		handler = router.notAllowed
+	}
+
 	handlerFunc := handler.handlerFunc
 
 	for _, m := range ep.Middlewares {
 		handlerFunc = m(handlerFunc)
 	}
 
+	for s := scope; s != nil; s = s.parent {
+		for _, m := range s.middlewares {
+			handlerFunc = m(handlerFunc)
+		}
+	}
+
 	// if handler.description.authRequired && af == nil {
 	// 	return NewError(http.StatusInternalServerError, "method not supported"), true
 	// }
@@ -72,9 +282,11 @@ type HttpHandler[C, A any] struct {
 	middlewares []RequestMiddleware[C, A]
 	container   C
 	authFunc    AuthFunc[A]
-	router      Router[C, A]
+	router      atomic.Pointer[Router[C, A]]
 	log         Logger
 	gzip        bool
+
+	requestErrorFormatter func(*RequestError) interface{}
 }
 
 func NewHttpHandler[C, A any](r Router[C, A], opt Options, middlewares ...RequestMiddleware[C, A]) *HttpHandler[C, A] {
@@ -84,12 +296,24 @@ func NewHttpHandler[C, A any](r Router[C, A], opt Options, middlewares ...Reques
 		log = &emptyLogger{}
 	}
 
-	return &HttpHandler[C, A]{
+	h := &HttpHandler[C, A]{
 		middlewares: middlewares,
-		router:      r,
 		log:         log,
 		gzip:        opt.SupportGZIP,
+
+		requestErrorFormatter: opt.RequestErrorFormatter,
 	}
+
+	h.router.Store(&r)
+
+	return h
+}
+
+// SetRouter replaces the router of the handler. It is safe to call it while the handler serves requests,
+// requests which are already in progress are finished with the previous router. The router must not be
+// changed after it was set.
+func (h *HttpHandler[C, A]) SetRouter(r Router[C, A]) {
+	h.router.Store(&r)
 }
 
 func (h *HttpHandler[C, A]) SetContainer(container C) *HttpHandler[C, A] {
@@ -109,7 +333,7 @@ func (h *HttpHandler[C, A]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		handler = m(handler)
 	}
 
-	result, ctn := handler(r.Context(), h.router, h.container, h.authFunc, w, r)
+	result, ctn := handler(r.Context(), *h.router.Load(), h.container, h.authFunc, w, r)
 	if !ctn {
 		return
 	}
@@ -120,6 +344,13 @@ func (h *HttpHandler[C, A]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	code := http.StatusOK
+
+	var reqErr *RequestError
+	if err, ok := result.(error); ok && h.requestErrorFormatter != nil && errors.As(err, &reqErr) {
+		code, result = reqErr.Code(), h.requestErrorFormatter(reqErr)
+	}
+
 	gzipAccept := strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
 
 	if gzipAccept && h.gzip {
@@ -157,35 +388,73 @@ func (h *HttpHandler[C, A]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	switch r := result.(type) {
 	case ResponseWithCode:
-		w.WriteHeader(r.Code())
+		code = r.Code()
 	case error:
-		w.WriteHeader(http.StatusInternalServerError)
-	default:
-		w.WriteHeader(http.StatusOK)
+		// the formatted request error keeps its code
+		if reqErr == nil {
+			code = http.StatusInternalServerError
+		}
 	}
 
-	if gzipAccept && h.gzip {
-		gw := gzip.NewWriter(w)
-		err = writeBody(gw, result)
+	if r.Method == http.MethodHead {
+		cw := &countWriter{}
+
+		err = writeResponse(cw, result, gzipAccept && h.gzip)
 		if err != nil {
 			h.log.Error(err)
-			return
 		}
 
-		err = gw.Close()
-		if err != nil {
-			h.log.Error(err)
+		if bodyAllowed(code) {
+			w.Header().Set("Content-Length", strconv.FormatInt(cw.n, 10))
 		}
 
+		w.WriteHeader(code)
+
+		return
+	}
+
+	w.WriteHeader(code)
+
+	if !bodyAllowed(code) {
 		return
 	}
 
-	err = writeBody(w, result)
+	err = writeResponse(w, result, gzipAccept && h.gzip)
 	if err != nil {
 		h.log.Error(err)
 	}
 }
 
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified && (code < 100 || code > 199)
+}
+
+// countWriter counts written bytes. It is used for calculate content length of the response to HEAD request.
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+
+	return len(p), nil
+}
+
+func writeResponse(w io.Writer, result interface{}, gz bool) error {
+	if !gz {
+		return writeBody(w, result)
+	}
+
+	gw := gzip.NewWriter(w)
+
+	err := writeBody(gw, result)
+	if err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
 func writeBody(w io.Writer, body interface{}) error {
 	var err error
 
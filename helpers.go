@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -21,9 +23,18 @@ func AuthOptional() Option {
 	}
 }
 
+// ValidationErrorCode sets the response code for errors returned by the Validate method of the request object.
+// It is 400 by default and errors which have own code are returned as is.
+func ValidationErrorCode(code int) Option {
+	return func(d *apiDescription) {
+		d.validationErrorCode = code
+	}
+}
+
 type fieldHandler struct {
-	tag string
-	fn  func(string, interface{}) error
+	tag      string
+	location string
+	fn       func(string, interface{}) error
 }
 
 func trim(tagValue string, value interface{}) error {
@@ -42,6 +53,20 @@ func setValue(targetValue interface{}, value string) error {
 	//	return fmt.Errorf("value ", value)
 	//}
 
+	// the nil pointer field is set only if the value is correct
+	if rv := reflect.ValueOf(targetValue); rv.Kind() == reflect.Pointer && rv.Elem().Kind() == reflect.Pointer {
+		nv := reflect.New(rv.Elem().Type().Elem())
+
+		err := setValue(nv.Interface(), value)
+		if err != nil {
+			return err
+		}
+
+		rv.Elem().Set(nv)
+
+		return nil
+	}
+
 	switch v := targetValue.(type) {
 	case *string:
 		*v = value
@@ -82,6 +107,42 @@ func setValue(targetValue interface{}, value string) error {
 	}
 }
 
+// setValues sets all values to the slice or the array by converting each of them by setValue.
+// Other fields get the first value.
+func setValues(targetValue interface{}, values []string) error {
+	rv := reflect.ValueOf(targetValue)
+
+	if rv.Kind() != reflect.Pointer {
+		return setValue(targetValue, values[0])
+	}
+
+	var slice reflect.Value
+
+	switch t := rv.Elem().Type(); {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		slice = reflect.MakeSlice(t, len(values), len(values))
+	case t.Kind() == reflect.Array:
+		if len(values) > t.Len() {
+			return fmt.Errorf("too many values, maximum is %d", t.Len())
+		}
+
+		slice = reflect.New(t).Elem()
+	default:
+		return setValue(targetValue, values[0])
+	}
+
+	for i, v := range values {
+		err := setValue(slice.Index(i).Addr().Interface(), v)
+		if err != nil {
+			return err
+		}
+	}
+
+	rv.Elem().Set(slice)
+
+	return nil
+}
+
 func parseArgs(tagValue string, targetValue interface{}, argsPlace []string, args []string) error {
 	var argValue string
 
@@ -91,16 +152,62 @@ func parseArgs(tagValue string, targetValue interface{}, argsPlace []string, arg
 		}
 	}
 
+	if argValue == "" {
+		return nil
+	}
+
+	// the wildcard argument contains the rest of the path and can be split by segments
+	if v, ok := targetValue.(*[]string); ok {
+		*v = strings.Split(argValue, "/")
+
+		return nil
+	}
+
 	return setValue(targetValue, argValue)
 }
 
+// Collection formats of the query parameters which are bound to slices and arrays, e.g. `query:"tag,csv"`
+const (
+	CollectionMulti = "multi"
+	CollectionCSV   = "csv"
+	CollectionPipes = "pipes"
+)
+
+// collectionSeparators contains separators of the values for collection formats
+var collectionSeparators = map[string]string{
+	"":              "",
+	CollectionMulti: "",
+	CollectionCSV:   ",",
+	CollectionPipes: "|",
+}
+
+func knownCollection(format string) bool {
+	_, ok := collectionSeparators[format]
+
+	return ok
+}
+
+// parseQuery binds the query parameter. Values of the repeated keys are bound to slices and arrays, and also
+// they are split if the collection format is set in the tag like `query:"tag,csv"`.
 func parseQuery(tagValue string, targetValue interface{}, url *url.URL) error {
-	queryValue := url.Query().Get(tagValue)
-	if queryValue == "" {
+	name, format, _ := strings.Cut(tagValue, ",")
+
+	queryValues := url.Query()[name]
+	if len(queryValues) == 0 || len(queryValues) == 1 && queryValues[0] == "" {
 		return nil
 	}
 
-	return setValue(targetValue, queryValue)
+	if sep := collectionSeparators[format]; sep != "" {
+		values := make([]string, 0, len(queryValues))
+
+		for _, v := range queryValues {
+			values = append(values, strings.Split(v, sep)...)
+		}
+
+		queryValues = values
+	}
+
+	return setValues(targetValue, queryValues)
 }
 
 func parseHeader(tagValue string, targetValue interface{}, header http.Header) error {
@@ -112,7 +219,9 @@ func parseHeader(tagValue string, targetValue interface{}, header http.Header) e
 	return setValue(targetValue, headerValue)
 }
 
-func handleStructFields(data interface{}, request *http.Request, handler ...fieldHandler) error {
+// handleStructFields fills the fields of the data by the handlers and returns errors for all fields which
+// can not be filled
+func handleStructFields(data interface{}, request *http.Request, handler ...fieldHandler) []*FieldError {
 	t := reflect.TypeOf(data)
 	v := reflect.ValueOf(data)
 
@@ -125,6 +234,8 @@ func handleStructFields(data interface{}, request *http.Request, handler ...fiel
 	//	panic("data is not a struct")
 	//}
 
+	var errs []*FieldError
+
 	for i := 0; i < t.NumField(); i++ {
 		ft := t.Field(i)
 		fv := v.Field(i)
@@ -144,18 +255,14 @@ func handleStructFields(data interface{}, request *http.Request, handler ...fiel
 
 			switch ft.Type.Kind() {
 			case reflect.Pointer:
-				// if fv.IsNil() {
-				// 	refValue := reflect.New(ft.Type.Elem())
-				// 	fv.Set(refValue)
-				// 	continue // TODO ...
-				// }
-
 				target = fv
-			case reflect.Struct:
-				err := handleStructFields(fv.Addr().Interface(), request, handler...)
-				if err != nil {
-					return err
+
+				// the nil pointer is allocated by the handler if the value is present
+				if fv.IsNil() {
+					target = fv.Addr()
 				}
+			case reflect.Struct:
+				errs = append(errs, handleStructFields(fv.Addr().Interface(), request, handler...)...)
 
 				continue
 			default:
@@ -164,66 +271,200 @@ func handleStructFields(data interface{}, request *http.Request, handler ...fiel
 
 			err := h.fn(tagValue, target.Interface())
 			if err != nil {
-				return err
+				errs = append(errs, &FieldError{
+					Field:    tagName(tagValue),
+					Location: h.location,
+					Code:     CodeInvalid,
+					Message:  err.Error(),
+					cause:    err,
+				})
 			}
 		}
 	}
 
-	return nil
+	return errs
 }
 
-func parseRequest(_ context.Context, data interface{}, r *http.Request, argsPlace []string, args []string) interface{} {
-	contentType := r.Header.Get("Content-Type")
+// acceptedMediaTypes are media types of the request body which can be bound to the request object
+var acceptedMediaTypes = []string{"application/json", "application/*+json", "application/x-www-form-urlencoded", "multipart/form-data"}
+
+// isJSON returns true for application/json and media types with the +json suffix like application/merge-patch+json
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
+
+func unsupportedMediaType(mediaType string) error {
+	return NewError(http.StatusUnsupportedMediaType, "unsupported media type [%s], accepted types: %s", mediaType, strings.Join(acceptedMediaTypes, ", "))
+}
+
+// tagName returns the tag value without options
+func tagName(tagValue string) string {
+	name, _, _ := strings.Cut(tagValue, ",")
+
+	return name
+}
 
-	switch {
-	case contentType == "application/json":
-		err := json.NewDecoder(r.Body).Decode(data)
+// bodyError converts the error of the JSON decoding to the field error
+func bodyError(err error) *FieldError {
+	fe := &FieldError{
+		Location: LocationBody,
+		Code:     CodeMalformed,
+		Message:  err.Error(),
+		cause:    err,
+	}
 
-		if err != nil && err != io.EOF {
-			//return NewResponse(response{
-			//	Status:      http.StatusBadRequest,
-			//	Code:        "incorrect-json-data",
-			//	Description: err.Error(),
-			//}).SetCode(http.StatusBadRequest)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		fe.Field = typeErr.Field
+		fe.Code = CodeInvalid
+		fe.Message = fmt.Sprintf("value must be %s", typeErr.Type.String())
+	}
+
+	return fe
+}
+
+// alreadyFailed returns true if the binding of the field is failed and it does not need to be validated.
+// Fields are not validated at all if the whole body or form is malformed.
+func alreadyFailed(errs []*FieldError, fe *FieldError) bool {
+	for _, e := range errs {
+		if e.Location == fe.Location && (e.Field == fe.Field || e.Field == "" && e.Code == CodeMalformed) {
+			return true
 		}
-	case strings.HasPrefix(contentType, "multipart/form-data"):
-		//mp, err := r.MultipartReader()
-		//
-		//if err != nil {
-		//	//return httpserver.NewResponse(response{
-		//	//	Status:      http.StatusBadRequest,
-		//	//	Code:        "incorrect-form-data-data",
-		//	//	Description: err.Error(),
-		//	//}).SetCode(http.StatusBadRequest)
-		//}
-		//
-		//if data, ok := data.(interface {
-		//	SetMultipart(*multipart.Reader)
-		//}); ok {
-		//	data.SetMultipart(mp)
-		//}
-	}
-
-	err := handleStructFields(data, r,
-		fieldHandler{"header", func(tag string, v interface{}) error { return parseHeader(tag, v, r.Header) }},
-		fieldHandler{"query", func(tag string, v interface{}) error { return parseQuery(tag, v, r.URL) }},
-		fieldHandler{"args", func(tag string, v interface{}) error { return parseArgs(tag, v, argsPlace, args) }},
-
-		fieldHandler{"json", trim},
+	}
+
+	return false
+}
+
+// parseRequest binds the request to the data. The returned function must be called after the handler returns,
+// it closes and removes uploaded files.
+func parseRequest(_ context.Context, data interface{}, d *apiDescription, r *http.Request, argsPlace []string, args []string) (interface{}, func()) {
+	var (
+		errs    []*FieldError
+		closers []io.Closer
+		cleanup = func() {}
 	)
 
-	if err != nil {
-		//return handlerResult(ctx, err)
+	contentType := r.Header.Get("Content-Type")
+
+	if contentType != "" && r.ContentLength != 0 {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+
+		switch {
+		case err != nil:
+			return unsupportedMediaType(contentType), cleanup
+		case isJSON(mediaType):
+			err = json.NewDecoder(r.Body).Decode(data)
+
+			if err != nil && err != io.EOF {
+				errs = append(errs, bodyError(err))
+			}
+		case mediaType == "application/x-www-form-urlencoded":
+			err = r.ParseForm()
+			if err != nil {
+				return &RequestError{Errors: []*FieldError{{
+					Location: LocationForm,
+					Code:     CodeMalformed,
+					Message:  err.Error(),
+					cause:    err,
+				}}}, cleanup
+			}
+		case mediaType == "multipart/form-data":
+			removeFiles, err := parseMultipart(r, d.maxMemory, d.maxDisk)
+			if err != nil {
+				return err, cleanup
+			}
+
+			cleanup = func() {
+				for _, c := range closers {
+					_ = c.Close()
+				}
+
+				removeFiles()
+			}
+		default:
+			return unsupportedMediaType(mediaType), cleanup
+		}
 	}
 
-	//err = data.Validate(r, args)
-	//if err != nil {
-	//	return handlerResult(ctx, err)
-	//}
+	form := r.PostForm
+	if r.MultipartForm != nil {
+		form = r.MultipartForm.Value
+	}
 
-	return nil
+	errs = append(errs, handleStructFields(data, r,
+		fieldHandler{"header", LocationHeader, func(tag string, v interface{}) error { return parseHeader(tag, v, r.Header) }},
+		fieldHandler{"query", LocationQuery, func(tag string, v interface{}) error { return parseQuery(tag, v, r.URL) }},
+		fieldHandler{"args", LocationArgs, func(tag string, v interface{}) error { return parseArgs(tag, v, argsPlace, args) }},
+		fieldHandler{"form", LocationForm, func(tag string, v interface{}) error { return parseForm(tag, v, form) }},
+		fieldHandler{"file", LocationForm, func(tag string, v interface{}) error { return parseFile(tag, v, r.MultipartForm, &closers) }},
+
+		fieldHandler{"json", LocationBody, trim},
+	)...)
+
+	for _, fe := range d.rules.validate(reflect.ValueOf(data), "", "") {
+		if !alreadyFailed(errs, fe) {
+			errs = append(errs, fe)
+		}
+	}
+
+	if len(errs) != 0 {
+		return &RequestError{Errors: errs}, cleanup
+	}
+
+	return nil, cleanup
+}
+
+// Validator is implemented by request objects which need to be checked by several fields together. The Validate
+// method is called after the request is bound and before the handler function.
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
+// ContainerValidator is like the Validator but it also gets the container, e.g. for checking values in the storage
+type ContainerValidator[C any] interface {
+	Validate(ctx context.Context, c C) error
+}
+
+// callValidator calls the Validate method of the request object. The error without own code is returned with
+// the passed code.
+func callValidator[C any](ctx context.Context, c C, request interface{}, code int) error {
+	var err error
+
+	switch v := request.(type) {
+	case Validator:
+		err = v.Validate(ctx)
+	case ContainerValidator[C]:
+		err = v.Validate(ctx, c)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	var coded ResponseWithCode
+	if errors.As(err, &coded) {
+		return err
+	}
+
+	return NewError(code, "%s", err)
 }
 
+// Create creates the method handler for the function. The request object is bound from the request by the
+// header, query, args, form, file and json tags. The form tag binds fields of the application/x-www-form-urlencoded
+// and multipart/form-data requests, repeated keys are bound to slices. The request object is checked by the
+// validate tag, e.g.
+//
+//	type Request struct {
+//		Limit int      `query:"limit" validate:"min=1,max=100"`
+//		Email string   `json:"email" validate:"required,email"`
+//		Tags  []string `json:"tags" validate:"max=10,dive,oneof=red green blue"`
+//		Code  string   `json:"code" validate:"len=6,pattern=^[0-9]+$"`
+//	}
+//
+// Supported rules are required, min, max, len, pattern, oneof, email and uuid. The min, max and len rules
+// check the length of strings, slices and maps. Rules after the dive are applied to the elements of the slice
+// and the pattern rule must be the last one. Nil pointers, empty strings, slices and maps are checked only by
+// the required rule. Fields of the nested structs are validated recursively. Create panics if the tags are incorrect.
 func Create[RQ, RP, A, C any](fn func(context.Context, C, A, RQ) (RP, error), options ...Option) *MethodHandler[C, A] {
 	var rq RQ
 	var rp RP
@@ -255,30 +496,44 @@ func Create[RQ, RP, A, C any](fn func(context.Context, C, A, RQ) (RP, error), op
 		successStatusCode = t.Code()
 	}
 
+	rules, err := newStructRules(rqRef)
+	if err != nil {
+		panic(fmt.Sprintf("incorrect tags of the request [%s]: %v", rqName, err))
+	}
+
 	params := parameters{}
 
 	var rqType, rpType *apiType
 
-	//rqType = definitionFromObject(rqRef, &params, "") TODO support recursive schema
+	rqType = definitionFromObject(rqRef, &params, "")
 
 	switch (interface{})(rp).(type) {
 	case NoContent, *Swagger:
 	default:
-		//rpType = definitionFromObject(rpRef, &params, "") TODO support recursive schema
+		rpType = definitionFromObject(rpRef, &parameters{}, "")
 	}
 
-	handler := &MethodHandler[C, A]{
+	var handler *MethodHandler[C, A]
+
+	handler = &MethodHandler[C, A]{
 		description: apiDescription{
 			headers: params.headers,
 			args:    params.args,
 			query:   params.query,
+			form:    params.form,
 
 			requestObject: objectType{
 				name:   rqName,
 				object: rqType,
 			},
 
-			successStatusCode: successStatusCode,
+			rules: rules,
+
+			successStatusCode:   successStatusCode,
+			validationErrorCode: http.StatusBadRequest,
+
+			maxMemory: defaultMaxMemory,
+			maxDisk:   -1,
 
 			responseObject: objectType{
 				name:        rpName,
@@ -297,10 +552,17 @@ func Create[RQ, RP, A, C any](fn func(context.Context, C, A, RQ) (RP, error), op
 					request = reflect.New(t.Elem()).Interface().(RQ)
 				}
 
-				res := parseRequest(ctx, request, r, argsPlace, args)
+				res, cleanup := parseRequest(ctx, request, &handler.description, r, argsPlace, args)
+				defer cleanup()
+
 				if res != nil {
 					return res
 				}
+
+				err := callValidator(ctx, c, request, handler.description.validationErrorCode)
+				if err != nil {
+					return err
+				}
 			}
 
 			result, err := fn(ctx, c, a, request)
@@ -391,10 +653,16 @@ func intFormat(k reflect.Kind) string {
 }
 
 type parameters struct {
-	headers, args, query OrderedMap[apiType]
+	headers, args, query, form OrderedMap[apiType]
 }
 
 func definitionFromObject(t reflect.Type, p *parameters, desc string) *apiType {
+	return schemaFromType(t, p, desc, map[reflect.Type]bool{})
+}
+
+// schemaFromType describes the type in the swagger schema. The struct which is already described on the upper
+// levels is described as an object without properties because the recursive schema is not supported.
+func schemaFromType(t reflect.Type, p *parameters, desc string, seen map[reflect.Type]bool) *apiType {
 	if t == nil {
 		return nil
 	}
@@ -405,64 +673,107 @@ func definitionFromObject(t reflect.Type, p *parameters, desc string) *apiType {
 
 	switch t.Kind() {
 	case reflect.Struct:
-		fields := make(OrderedMap[apiType], 0, t.NumField())
+		if seen[t] {
+			return &apiType{
+				Type:        TypeObject,
+				Description: desc,
+			}
+		}
+
+		seen[t] = true
+		defer delete(seen, t)
+
+		obj := &apiType{
+			Type:        TypeObject,
+			Description: desc,
+			Properties:  make(OrderedMap[apiType], 0, t.NumField()),
+		}
 
 		for i := 0; i < t.NumField(); i++ {
 			f := t.Field(i)
 
+			if !f.IsExported() {
+				continue
+			}
+
 			dd := f.Tag.Get("desc")
+			rules, elem, _ := parseRules(f.Tag.Get("validate"))
 
-			if h := f.Tag.Get("header"); h != "" {
-				p.headers.Add(h, apiType{
-					Type:        TypeString, // TODO
-					Description: dd,
-					Required:    false,
-				})
+			// the fields which are bound from the request are described as parameters
+			if name, location := fieldName(f); location != "" {
+				param := schemaFromType(f.Type, &parameters{}, dd, seen)
+				if param == nil {
+					param = &apiType{Type: TypeString, Description: dd}
+				}
 
-				continue
-			}
+				if f.Tag.Get("file") != "" {
+					param = &apiType{Type: TypeFile, Description: dd}
+				}
 
-			if h := f.Tag.Get("args"); h != "" {
-				p.args.Add(h, apiType{
-					Type:        TypeString, // TODO
-					Description: dd,
-					Required:    true,
-				})
+				// repeated keys are bound to the slice
+				if param.Type == TypeArray && (location == LocationForm || location == LocationQuery) {
+					param.CollectionFormat = CollectionMulti
+
+					if _, format, _ := strings.Cut(f.Tag.Get("query"), ","); format != "" && location == LocationQuery {
+						param.CollectionFormat = format
+					}
+				}
+
+				param.Properties = nil
+				applyRules(param, rules)
+
+				if param.Items != nil {
+					applyRules(param.Items, elem)
+				}
+
+				switch location {
+				case LocationHeader:
+					p.headers.Add(name, *param)
+				case LocationArgs:
+					param.Required = true
+					p.args.Add(name, *param)
+				case LocationQuery:
+					p.query.Add(name, *param)
+				case LocationForm:
+					p.form.Add(name, *param)
+				}
 
 				continue
 			}
 
-			if h := f.Tag.Get("query"); h != "" {
-				p.query.Add(h, apiType{
-					Type:        TypeString, // TODO
-					Description: dd,
-					Required:    false,
-				})
-
+			jsonTag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if jsonTag == "-" {
 				continue
 			}
 
-			jsonTag := f.Tag.Get("json")
 			if jsonTag == "" {
 				jsonTag = f.Name
 			}
 
-			parameter := definitionFromObject(f.Type, p, dd)
-			if parameter != nil {
-				fields.Add(jsonTag, *parameter)
+			parameter := schemaFromType(f.Type, p, dd, seen)
+			if parameter == nil {
+				continue
 			}
-		}
 
-		return &apiType{
-			Type:        TypeObject,
-			Description: desc,
-			Properties:  fields,
+			applyRules(parameter, rules)
+
+			if parameter.Items != nil {
+				applyRules(parameter.Items, elem)
+			}
+
+			if parameter.Required {
+				obj.RequiredProperties = append(obj.RequiredProperties, jsonTag)
+			}
+
+			obj.Properties.Add(jsonTag, *parameter)
 		}
-	case reflect.Slice:
+
+		return obj
+	case reflect.Slice, reflect.Array:
 		return &apiType{
 			Type:        TypeArray,
 			Description: desc,
-			Items:       definitionFromObject(t.Elem(), p, ""),
+			Items:       schemaFromType(t.Elem(), p, "", seen),
 		}
 	case reflect.String:
 		return &apiType{
@@ -475,6 +786,11 @@ func definitionFromObject(t reflect.Type, p *parameters, desc string) *apiType {
 			Description: desc,
 			Format:      intFormat(t.Kind()),
 		}
+	case reflect.Float32, reflect.Float64:
+		return &apiType{
+			Type:        TypeNumber,
+			Description: desc,
+		}
 	case reflect.Bool:
 		return &apiType{
 			Type:        TypeBool,
@@ -0,0 +1,73 @@
+package httpserver
+
+import (
+	"path"
+	"strings"
+)
+
+// PathOpt is options of the path normalization which is done before the route matching
+type PathOpt struct {
+	// CleanPath removes double slashes and dot segments from the path
+	CleanPath bool
+
+	// TrailingSlash matches the path with or without trailing slash to the route
+	TrailingSlash bool
+
+	// CaseInsensitive matches static segments of the path to the route ignoring case.
+	// The request is never redirected because of the case.
+	CaseInsensitive bool
+
+	// RedirectCode is a http code (http.StatusMovedPermanently or http.StatusPermanentRedirect)
+	// for redirect the client to the cleaned path or the path with fixed trailing slash.
+	// If the code is not set the fixed path is matched silently.
+	RedirectCode int
+}
+
+// NormalizePath sets options of the path normalization
+func (r *Router[C, A]) NormalizePath(opt PathOpt) *Router[C, A] {
+	r.pathOpt = opt
+
+	return r
+}
+
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	if p[0] != '/' {
+		p = "/" + p
+	}
+
+	res := path.Clean(p)
+
+	// path.Clean removes the trailing slash
+	if strings.HasSuffix(p, "/") && res != "/" {
+		res += "/"
+	}
+
+	return res
+}
+
+// match finds a route for the path with respect to the normalization options.
+// It returns the path which was matched to the route.
+func (r *Router[C, A]) match(host, p string) (*route[C, A], []string, string) {
+	if r.pathOpt.CleanPath {
+		p = cleanPath(p)
+	}
+
+	rt, args := r.get(host, p)
+	if rt != nil || !r.pathOpt.TrailingSlash || p == "/" {
+		return rt, args, p
+	}
+
+	if strings.HasSuffix(p, "/") {
+		p = strings.TrimSuffix(p, "/")
+	} else {
+		p += "/"
+	}
+
+	rt, args = r.get(host, p)
+
+	return rt, args, p
+}
@@ -3,77 +3,385 @@ package httpserver
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-var placeholderReg *regexp.Regexp
-
-func init() {
-	var err error
-
-	placeholderReg, err = regexp.Compile("{([^/]+)}")
-	if err != nil {
-		panic(err)
-	}
-}
-
 type route[C, A any] struct {
 	path    string
-	pattern *regexp.Regexp
+	params  []placeholder
 	args    []string
 	handler Handler[C, A]
+	scope   *SubRouter[C, A]
+	host    *hostRoute[C, A]
+
+	// base is true if the handler was added without version
+	base     bool
+	versions OrderedMap[Handler[C, A]]
+}
+
+// hostRoute contains routes which are matched only for requests to the host
+type hostRoute[C, A any] struct {
+	pattern string
+	reg     *regexp.Regexp
+	params  []placeholder
+	tree    *node[C, A]
 }
 
 // Router is a router object
 type Router[C, A any] struct {
-	routes       []route[C, A]
+	tree         *node[C, A]
+	hosts        []*hostRoute[C, A]
+	routes       []*route[C, A]
+	names        map[string]*route[C, A]
 	defaultRoute *Handler[C, A]
+	defaults     []*route[C, A]
+	notAllowed   *MethodHandler[C, A]
+	pathOpt      PathOpt
+	versionOpt   VersionOpt
+	errs         []error
 }
 
 // SubRouter is a sub router object
 type SubRouter[C, A any] struct {
-	r       *Router[C, A]
-	subPath string
+	r           *Router[C, A]
+	parent      *SubRouter[C, A]
+	host        *hostRoute[C, A]
+	subPath     string
+	middlewares []HandlerMiddleware[C, A]
 }
 
 // NewRouter creates and returns new router
 func NewRouter[C, A any]() Router[C, A] {
-	return Router[C, A]{}
+	return Router[C, A]{
+		tree: &node[C, A]{},
+	}
 }
 
-// Add new router rule in to router object for handler
+// Add new router rule in to router object for handler. If the route can not be added
+// the error is saved and returned by Validate.
 func (r *Router[C, A]) Add(path string, h Handler[C, A]) *Router[C, A] {
-	res := placeholderReg.FindAllStringSubmatch(path, -1)
-	args := make([]string, 0, len(res))
-	for _, r := range res {
-		args = append(args, r[1])
+	err := r.AddE(path, h)
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
+
+	return r
+}
+
+// AddE adds new router rule in to router object for handler and returns an error
+// if the path is incorrect or the route is conflicted with already added route
+func (r *Router[C, A]) AddE(path string, h Handler[C, A]) error {
+	return r.add(path, "", h, nil)
+}
+
+func (r *Router[C, A]) add(path, version string, h Handler[C, A], scope *SubRouter[C, A]) error {
+	segments, params, err := parsePath(path)
+	if err != nil {
+		return &RouteError{Path: path, Err: err}
 	}
 
-	reg, err := regexp.Compile(fmt.Sprintf("^%s$", placeholderReg.ReplaceAllString(path, "([^/]+)")))
+	h, err = h.normalizeMethods()
 	if err != nil {
-		panic(err) // TODO
+		return &RouteError{Path: path, Err: err}
 	}
 
-	r.routes = append(r.routes, route[C, A]{
-		path:    path,
-		pattern: reg,
-		args:    args,
-		handler: h,
-	})
+	if r.tree == nil {
+		r.tree = &node[C, A]{}
+	}
+
+	tree := r.tree
+
+	args := make([]string, 0, len(params))
+
+	var host *hostRoute[C, A]
+
+	if scope != nil && scope.host != nil {
+		host = scope.host
+		tree = host.tree
+
+		// host arguments are placed before path arguments
+		for _, p := range host.params {
+			args = append(args, p.name)
+		}
+	}
+
+	if rt, ok := r.names[h.Name]; ok && h.Name != "" && rt.path != path {
+		return &RouteError{Path: path, Err: fmt.Errorf("route name [%s] is already used by [%s]", h.Name, rt.path)}
+	}
+
+	var nodes []*node[C, A]
+
+	switch {
+	case h.mount == nil:
+		nodes = append(nodes, tree.insert(segments))
+	case len(segments) == 1 && segments[0].key == "":
+		nodes = append(nodes, tree.insert([]segment{mountSegment}))
+	default:
+		nodes = append(nodes, tree.insert(segments), tree.insert(append(segments, mountSegment)))
+	}
+
+	// the first added route has priority
+	for _, n := range nodes {
+		if n.route == nil {
+			continue
+		}
+
+		if n.route.path == path && h.mount == nil && n.route.handler.mount == nil {
+			err = n.route.addVersion(version, h)
+			if err != nil {
+				return &RouteError{Path: path, Err: err}
+			}
+
+			r.addName(h.Name, n.route)
+
+			return nil
+		}
+
+		if n.route.path == path {
+			return &RouteError{Path: path, Err: fmt.Errorf("duplicate route")}
+		}
+
+		return &RouteError{Path: path, Err: fmt.Errorf("route is unreachable, it is shadowed by [%s]", n.route.path)}
+	}
+
+	for _, p := range params {
+		args = append(args, p.name)
+	}
+
+	rt := &route[C, A]{
+		path:   path,
+		params: params,
+		args:   args,
+		scope:  scope,
+		host:   host,
+	}
+
+	err = rt.addVersion(version, h)
+	if err != nil {
+		return &RouteError{Path: path, Err: err}
+	}
+
+	for _, n := range nodes {
+		n.route = rt
+	}
+
+	r.routes = append(r.routes, rt)
+	r.addName(h.Name, rt)
+
+	return nil
+}
+
+func (r *Router[C, A]) addName(name string, rt *route[C, A]) {
+	if name == "" {
+		return
+	}
+
+	if r.names == nil {
+		r.names = make(map[string]*route[C, A])
+	}
+
+	r.names[name] = rt
+}
+
+// URL returns the path of the named route with placeholders replaced by escaped values of params.
+// All placeholders of the route must be passed and any other params are not allowed. Only values of
+// wildcard placeholders can contain slashes.
+func (r *Router[C, A]) URL(name string, params map[string]string) (string, error) {
+	rt, ok := r.names[name]
+	if !ok {
+		return "", fmt.Errorf("route [%s] not found", name)
+	}
+
+	pos, err := placeholders(rt.path)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		res  strings.Builder
+		last = 0
+		used = 0
+	)
+
+	for _, p := range pos {
+		param, constraint, err := parsePlaceholder(rt.path[p[0]+1 : p[1]-1])
+		if err != nil {
+			return "", err
+		}
+
+		value, ok := params[param.name]
+		if !ok {
+			return "", fmt.Errorf("param [%s] of route [%s] is missing", param.name, name)
+		}
+
+		used++
+
+		if constraint != "" {
+			if ok, _ := regexp.MatchString(fmt.Sprintf("^(?:%s)$", constraint), value); !ok {
+				return "", fmt.Errorf("value [%s] of param [%s] does not match the constraint [%s]", value, param.name, param.constraint)
+			}
+		}
+
+		res.WriteString(rt.path[last:p[0]])
+
+		if param.wildcard {
+			parts := strings.Split(value, "/")
+			for i, v := range parts {
+				parts[i] = url.PathEscape(v)
+			}
+
+			res.WriteString(strings.Join(parts, "/"))
+		} else {
+			if value == "" {
+				return "", fmt.Errorf("param [%s] of route [%s] is empty", param.name, name)
+			}
+
+			// the route is matched by the unescaped path so the value can not be a several segments
+			if strings.Contains(value, "/") {
+				return "", fmt.Errorf("value [%s] of param [%s] must not contain [/]", value, param.name)
+			}
+
+			res.WriteString(url.PathEscape(value))
+		}
+
+		last = p[1]
+	}
+
+	if used != len(params) {
+		for k := range params {
+			if !containsArg(rt.params, k) {
+				return "", fmt.Errorf("param [%s] is not used by route [%s]", k, name)
+			}
+		}
+	}
+
+	res.WriteString(rt.path[last:])
+
+	return res.String(), nil
+}
+
+func containsArg(params []placeholder, name string) bool {
+	for _, p := range params {
+		if p.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Mount adds the standard http handler for all requests with the path prefix. The prefix is stripped
+// from the path of the request which is passed to the handler. Request middlewares are applied
+// to the mounted handler but the handler middlewares are not.
+func (r *Router[C, A]) Mount(prefix string, h http.Handler) *Router[C, A] {
+	return r.mount(prefix, Handler[C, A]{mount: h})
+}
+
+func (r *Router[C, A]) mount(prefix string, h Handler[C, A]) *Router[C, A] {
+	err := r.add(mountPath(prefix), "", h, nil)
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
 
 	return r
 }
 
+func mountPath(prefix string) string {
+	if p := strings.TrimSuffix(prefix, "/"); p != "" {
+		return p
+	}
+
+	return "/"
+}
+
+// Validate returns errors of all routes which were not added and reports
+// ambiguous routes which can be matched to the same path
+func (r *Router[C, A]) Validate() error {
+	errs := make(RouteErrors, 0, len(r.errs))
+	errs = append(errs, r.errs...)
+
+	if r.tree != nil {
+		r.tree.ambiguous("", &errs)
+	}
+
+	for _, h := range r.hosts {
+		h.tree.ambiguous(h.pattern, &errs)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
 // Default sets a default handler for handle request if route rule was not found
 func (r *Router[C, A]) Default(h Handler[C, A]) *Router[C, A] {
+	h, err := h.normalizeMethods()
+	if err != nil {
+		r.errs = append(r.errs, &RouteError{Path: "*", Err: err})
+	}
+
 	r.defaultRoute = &h
 
 	return r
 }
 
+// MethodNotAllowed sets a handler for handle request if the route was found but it does not support the request method.
+// The allowed methods are available in the handler by AllowedMethods.
+func (r *Router[C, A]) MethodNotAllowed(h *MethodHandler[C, A]) *Router[C, A] {
+	r.notAllowed = h
+
+	return r
+}
+
+// Host returns new sub route object for add rules which are matched only for requests to the host.
+// The host pattern can contain placeholders like {tenant}.example.com which are passed to the handler
+// as arguments. Routes without the host are used if the route was not found for the host.
+// Routes of hosts are not described in the swagger.
+func (r *Router[C, A]) Host(pattern string) *SubRouter[C, A] {
+	var host *hostRoute[C, A]
+
+	for _, h := range r.hosts {
+		if h.pattern == pattern {
+			host = h
+		}
+	}
+
+	if host == nil {
+		reg, params, err := parseHost(pattern)
+		if err != nil {
+			r.errs = append(r.errs, &RouteError{Path: pattern, Err: err})
+		}
+
+		host = &hostRoute[C, A]{
+			pattern: pattern,
+			reg:     reg,
+			params:  params,
+			tree:    &node[C, A]{},
+		}
+
+		r.hosts = append(r.hosts, host)
+
+		// hosts without placeholders are more specific and are matched first
+		sort.SliceStable(r.hosts, func(i, j int) bool {
+			return len(r.hosts[i].params) == 0 && len(r.hosts[j].params) > 0
+		})
+	}
+
+	return &SubRouter[C, A]{
+		r:    r,
+		host: host,
+	}
+}
+
 // SubRoute returns new sub route object for add rules in sub root
 func (r *Router[C, A]) SubRoute(subPath string) *SubRouter[C, A] {
 	return &SubRouter[C, A]{
@@ -84,7 +392,75 @@ func (r *Router[C, A]) SubRoute(subPath string) *SubRouter[C, A] {
 
 // Add new router rule in to router object for handler
 func (r *SubRouter[C, A]) Add(subPath string, h Handler[C, A]) *SubRouter[C, A] {
-	r.r.Add(path.Join(r.subPath, subPath), h)
+	err := r.AddE(subPath, h)
+	if err != nil {
+		r.r.errs = append(r.r.errs, err)
+	}
+
+	return r
+}
+
+// AddE adds new router rule in to router object for handler and returns an error if the route can not be added
+func (r *SubRouter[C, A]) AddE(subPath string, h Handler[C, A]) error {
+	return r.r.add(path.Join(r.subPath, subPath), "", h, r)
+}
+
+// Default sets a default handler for handle request if route rule was not found in the sub router.
+// The default handler of the sub router with the longest matching prefix is used.
+func (r *SubRouter[C, A]) Default(h Handler[C, A]) *SubRouter[C, A] {
+	h, err := h.normalizeMethods()
+	if err != nil {
+		r.r.errs = append(r.r.errs, &RouteError{Path: r.subPath + "/*", Err: err})
+	}
+
+	for _, d := range r.r.defaults {
+		if d.path == r.subPath && d.host == r.host {
+			d.handler, d.scope = h, r
+
+			return r
+		}
+	}
+
+	r.r.defaults = append(r.r.defaults, &route[C, A]{
+		path:    r.subPath,
+		handler: h,
+		scope:   r,
+		host:    r.host,
+		base:    true,
+	})
+
+	return r
+}
+
+// Mount adds the standard http handler for all requests with the path prefix in the sub router
+func (r *SubRouter[C, A]) Mount(prefix string, h http.Handler) *SubRouter[C, A] {
+	return r.mount(prefix, Handler[C, A]{mount: h})
+}
+
+func (r *SubRouter[C, A]) mount(prefix string, h Handler[C, A]) *SubRouter[C, A] {
+	err := r.r.add(mountPath(path.Join(r.subPath, prefix)), "", h, r)
+	if err != nil {
+		r.r.errs = append(r.r.errs, err)
+	}
+
+	return r
+}
+
+// SubRoute returns new nested sub route object. Middlewares of the sub router are applied to routes of the nested one.
+func (r *SubRouter[C, A]) SubRoute(subPath string) *SubRouter[C, A] {
+	return &SubRouter[C, A]{
+		r:       r.r,
+		parent:  r,
+		host:    r.host,
+		subPath: path.Join(r.subPath, subPath),
+	}
+}
+
+// Use adds middlewares for all routes of the sub router and nested sub routers. Middlewares of the parent
+// sub router wrap middlewares of the nested one and middlewares of the handler are called last.
+// As for Handler.Middlewares, each next middleware wraps the previous one.
+func (r *SubRouter[C, A]) Use(middlewares ...HandlerMiddleware[C, A]) *SubRouter[C, A] {
+	r.middlewares = append(r.middlewares, middlewares...)
 
 	return r
 }
@@ -92,15 +468,62 @@ func (r *SubRouter[C, A]) Add(subPath string, h Handler[C, A]) *SubRouter[C, A]
 func appendParameters(params *[]apiParameter, values OrderedMap[apiType], in string) {
 	for _, v := range values {
 		*params = append(*params, apiParameter{
-			In:       in,
-			Name:     v.name,
-			Type:     v.value.Type,
-			Required: v.value.Required,
+			In:          in,
+			Name:        v.name,
+			Type:        v.value.Type,
+			Description: v.value.Description,
+			Required:    v.value.Required,
+			Format:      v.value.Format,
+			Pattern:     v.value.Pattern,
+			Minimum:     v.value.Minimum,
+			Maximum:     v.value.Maximum,
+			MinLength:   v.value.MinLength,
+			MaxLength:   v.value.MaxLength,
+			MinItems:    v.value.MinItems,
+			MaxItems:    v.value.MaxItems,
+			Enum:        v.value.Enum,
+			Items:       v.value.Items,
+
+			CollectionFormat: v.value.CollectionFormat,
 		})
 	}
 }
 
-func descriptionHandler[C, A any](handler *MethodHandler[C, A], definitions *OrderedMap[apiType], withBody bool) *apiHandler {
+// pathArgs describes the path placeholders by its constraints
+func pathArgs(params []placeholder, args OrderedMap[apiType]) OrderedMap[apiType] {
+	res := make(OrderedMap[apiType], 0, len(params))
+
+	for _, p := range params {
+		t := apiType{
+			Type: TypeString,
+		}
+
+		for _, a := range args {
+			if a.name == p.name {
+				t = a.value
+			}
+		}
+
+		t.Required = true
+
+		switch p.constraint {
+		case "":
+		case "int", "uint":
+			t.Type = TypeInteger
+			t.Format = "int64"
+		case "uuid":
+			t.Format = "uuid"
+		default:
+			t.Pattern = fmt.Sprintf("^%s$", p.constraint)
+		}
+
+		res.Add(p.name, t)
+	}
+
+	return res
+}
+
+func descriptionHandler[C, A any](handler *MethodHandler[C, A], params []placeholder, definitions *OrderedMap[apiType], withBody bool) *apiHandler {
 	if handler == nil {
 		return nil
 	}
@@ -108,12 +531,24 @@ func descriptionHandler[C, A any](handler *MethodHandler[C, A], definitions *Ord
 	descHandler := &apiHandler{}
 
 	appendParameters(&descHandler.Parameters, handler.description.headers, "header")
-	appendParameters(&descHandler.Parameters, handler.description.args, "path")
+	appendParameters(&descHandler.Parameters, pathArgs(params, handler.description.args), "path")
 	appendParameters(&descHandler.Parameters, handler.description.query, "query")
+	appendParameters(&descHandler.Parameters, handler.description.form, "formData")
+
+	if len(handler.description.form) != 0 {
+		descHandler.Consumes = []string{"application/x-www-form-urlencoded", "multipart/form-data"}
+	}
+
+	// files can be uploaded only by the multipart form
+	for _, f := range handler.description.form {
+		if f.value.Type == TypeFile {
+			descHandler.Consumes = []string{"multipart/form-data"}
+		}
+	}
 
 	obj := handler.description.requestObject
 
-	if withBody && len(obj.object.Properties) > 0 {
+	if withBody && obj.object != nil && len(obj.object.Properties) > 0 {
 		definitions.Add(obj.name, *obj.object)
 
 		descHandler.Parameters = append(descHandler.Parameters, apiParameter{
@@ -130,12 +565,15 @@ func descriptionHandler[C, A any](handler *MethodHandler[C, A], definitions *Ord
 		Description: handler.description.responseObject.description,
 	}
 
-	if obj := handler.description.responseObject; obj.object != nil {
+	// only named structs are added to definitions, other types are described inline
+	if obj := handler.description.responseObject; obj.object != nil && obj.object.Type == TypeObject && obj.name != "" {
 		definitions.Add(obj.name, *obj.object)
 
 		respDefinition.Schema = &apiSchema{
 			Ref: fmt.Sprintf("#/definitions/%s", obj.name),
 		}
+	} else if obj.object != nil {
+		respDefinition.Schema = &apiSchema{apiType: *obj.object}
 	}
 
 	descHandler.Responses.Add(strconv.Itoa(handler.description.successStatusCode), respDefinition)
@@ -143,6 +581,17 @@ func descriptionHandler[C, A any](handler *MethodHandler[C, A], definitions *Ord
 	return descHandler
 }
 
+// swaggerMethod returns name of the operation in the path item. Non-standard methods
+// are not supported by swagger and are described as vendor extensions.
+func swaggerMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return strings.ToLower(method)
+	default:
+		return "x-" + strings.ToLower(method)
+	}
+}
+
 func (r *Router[C, A]) renderSwagger(prefix string, opt SwaggerOpt) func(_ context.Context, _ C, _ A, _ struct{}) (*Swagger, error) {
 	opt.fillDefault(prefix)
 
@@ -158,7 +607,8 @@ func (r *Router[C, A]) renderSwagger(prefix string, opt SwaggerOpt) func(_ conte
 		}
 
 		for _, rt := range r.routes {
-			if !strings.HasPrefix(rt.path, prefix) {
+			// the spec has only one host, so routes of hosts would be mixed with the same paths without the host
+			if !strings.HasPrefix(rt.path, prefix) || rt.handler.mount != nil || rt.host != nil {
 				continue
 			}
 
@@ -168,13 +618,29 @@ func (r *Router[C, A]) renderSwagger(prefix string, opt SwaggerOpt) func(_ conte
 				pp = "/"
 			}
 
-			swagger.Paths.Add(pp, apiEndpoint{
-				Get:    descriptionHandler(rt.handler.Get, &swagger.Definitions, false),
-				Post:   descriptionHandler(rt.handler.Post, &swagger.Definitions, true),
-				Put:    descriptionHandler(rt.handler.Put, &swagger.Definitions, true),
-				Delete: descriptionHandler(rt.handler.Delete, &swagger.Definitions, true),
-				Patch:  descriptionHandler(rt.handler.Patch, &swagger.Definitions, true),
-			})
+			version := opt.APIVersion
+			if version == "" {
+				version = r.versionOpt.Default
+			}
+
+			h, ok := rt.version(version)
+			if !ok && rt.base {
+				h, ok = &rt.handler, true
+			}
+
+			if !ok {
+				continue
+			}
+
+			endpoint := apiEndpoint{}
+
+			for _, m := range h.handlers() {
+				withBody := m.name != http.MethodGet && m.name != http.MethodHead
+
+				endpoint.Add(swaggerMethod(m.name), descriptionHandler(m.value, rt.params, &swagger.Definitions, withBody))
+			}
+
+			swagger.Paths.Add(cleanPlaceholders(pp), endpoint)
 		}
 
 		return swagger, nil
@@ -187,6 +653,10 @@ type SwaggerOpt struct {
 	Description string
 
 	BasePath string
+
+	// APIVersion is a version of routes which were added by AddVersion, by default the default
+	// version of the router is used. Routes without version are described if the version of the route is not found.
+	APIVersion string
 }
 
 func (o *SwaggerOpt) fillDefault(prefix string) {
@@ -194,6 +664,10 @@ func (o *SwaggerOpt) fillDefault(prefix string) {
 		o.Title = "Some API"
 	}
 
+	if o.Version == "" {
+		o.Version = o.APIVersion
+	}
+
 	if o.Version == "" {
 		o.Version = "v0.0"
 	}
@@ -219,16 +693,62 @@ func (r *Router[C, A]) AddSwaggerSubRoute(path string, sr *SubRouter[C, A], opt
 	})
 }
 
-func (r *Router[C, A]) get(path string) (*Handler[C, A], []string, []string) {
-	for _, r := range r.routes {
-		if res := r.pattern.FindStringSubmatch(path); len(res) > 0 {
-			return &r.handler, r.args, res[1:]
+func (r *Router[C, A]) get(host, path string) (*route[C, A], []string) {
+	path = strings.TrimPrefix(path, "/")
+
+	if len(r.hosts) > 0 {
+		host = stripPort(host)
+
+		for _, h := range r.hosts {
+			if h.reg == nil {
+				continue
+			}
+
+			values := h.reg.FindStringSubmatch(host)
+			if values == nil {
+				continue
+			}
+
+			if rt, args := h.tree.lookup(path, values[1:], r.pathOpt.CaseInsensitive); rt != nil {
+				return rt, args
+			}
 		}
 	}
 
-	if r.defaultRoute != nil {
-		return r.defaultRoute, nil, nil
+	if r.tree == nil {
+		return nil, nil
+	}
+
+	return r.tree.lookup(path, nil, r.pathOpt.CaseInsensitive)
+}
+
+// defaultFor returns the default route of the sub router with the longest prefix of the path
+func (r *Router[C, A]) defaultFor(host, path string) *route[C, A] {
+	var res *route[C, A]
+
+	for _, d := range r.defaults {
+		if d.host != nil && (d.host.reg == nil || !d.host.reg.MatchString(stripPort(host))) {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(d.path, "/")
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+
+		// the sub router of the host is more specific than the same sub router without the host
+		if res == nil || len(prefix) > len(strings.TrimSuffix(res.path, "/")) || len(d.path) == len(res.path) && res.host == nil {
+			res = d
+		}
+	}
+
+	return res
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
 	}
 
-	return nil, nil, nil
+	return host
 }
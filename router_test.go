@@ -0,0 +1,274 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterLookup(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/user/{user-id}", handler{})
+	router.Add("/user/me", handler{})
+	router.Add("/user/{user-id}/file/{name}.{ext}", handler{})
+	router.Add("/user/{user-id}/file/{name}", handler{})
+	router.Add("/", handler{})
+
+	tests := []struct {
+		path      string
+		route     string
+		argsPlace []string
+		args      []string
+	}{
+		{"/", "/", []string{}, nil},
+		{"/user/me", "/user/me", []string{}, nil},
+		{"/user/123", "/user/{user-id}", []string{"user-id"}, []string{"123"}},
+		{"/user/123/file/doc.txt", "/user/{user-id}/file/{name}.{ext}", []string{"user-id", "name", "ext"}, []string{"123", "doc", "txt"}},
+		{"/user/123/file/doc", "/user/{user-id}/file/{name}", []string{"user-id", "name"}, []string{"123", "doc"}},
+		{"/user/", "", nil, nil},
+		{"/user/123/", "", nil, nil},
+		{"/unknown", "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		rt, args := router.tree.lookup(tt.path[1:], nil, false)
+
+		if tt.route == "" {
+			assert(t, rt, (*route[*TestContainer, *TestUserData])(nil))
+			continue
+		}
+
+		if rt == nil {
+			t.Errorf("route for [%s] was not found", tt.path)
+			continue
+		}
+
+		assert(t, rt.path, tt.route)
+		assert(t, rt.args, tt.argsPlace)
+		assert(t, args, tt.args)
+	}
+}
+
+func TestRouterCaseInsensitive(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/Docs", handler{})
+	router.Add("/docs/{name}", handler{})
+	router.Add("/DOCS", handler{})
+	router.Add("/dOcs", handler{})
+
+	for i := 0; i < 20; i++ {
+		rt, _ := router.tree.lookup("docs", nil, true)
+		assert(t, rt.path, "/DOCS")
+
+		rt, _ = router.tree.lookup("DOCS/readme", nil, true)
+		assert(t, rt.path, "/docs/{name}")
+	}
+
+	rt, _ := router.tree.lookup("docs", nil, false)
+	assert(t, rt, (*route[*TestContainer, *TestUserData])(nil))
+}
+
+func TestRouterConstraints(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/user/{name}", handler{})
+	router.Add("/user/{id:int}", handler{})
+	router.Add("/item/{uuid:uuid}", handler{})
+	router.Add("/post/{slug:[a-z0-9-]+}", handler{})
+	router.Add("/lang/{code:[a-z]{2}}.json", handler{})
+
+	tests := []struct {
+		path  string
+		route string
+		args  []string
+	}{
+		{"/user/42", "/user/{id:int}", []string{"42"}},
+		{"/user/-42", "/user/{id:int}", []string{"-42"}},
+		{"/user/abc", "/user/{name}", []string{"abc"}},
+		{"/item/0b8ea2e1-8d6d-4b55-9f0e-62d1c5b08d1d", "/item/{uuid:uuid}", []string{"0b8ea2e1-8d6d-4b55-9f0e-62d1c5b08d1d"}},
+		{"/item/abc", "", nil},
+		{"/post/some-post-1", "/post/{slug:[a-z0-9-]+}", []string{"some-post-1"}},
+		{"/post/Some_Post", "", nil},
+		{"/lang/en.json", "/lang/{code:[a-z]{2}}.json", []string{"en"}},
+		{"/lang/eng.json", "", nil},
+	}
+
+	for _, tt := range tests {
+		rt, args := router.get("", tt.path)
+
+		if tt.route == "" {
+			assert(t, rt, (*route[*TestContainer, *TestUserData])(nil))
+			continue
+		}
+
+		if rt == nil {
+			t.Errorf("route for [%s] was not found", tt.path)
+			continue
+		}
+
+		assert(t, rt.path, tt.route)
+		assert(t, args, tt.args)
+	}
+
+	assert(t, cleanPlaceholders("/lang/{code:[a-z]{2}}/{id:int}/{name}"), "/lang/{code}/{id}/{name}")
+
+	_, _, err := parsePath("/post/{slug:(a|b)}")
+	assert(t, err != nil, true)
+}
+
+func TestRouterWildcard(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/files/{path...}", handler{})
+	router.Add("/files/special", handler{})
+
+	rt, args := router.get("", "/files/a/b/c.txt")
+	assert(t, rt.path, "/files/{path...}")
+	assert(t, rt.args, []string{"path"})
+	assert(t, args, []string{"a/b/c.txt"})
+
+	rt, args = router.get("", "/files/")
+	assert(t, rt.path, "/files/{path...}")
+	assert(t, args, []string{""})
+
+	rt, _ = router.get("", "/files/special")
+	assert(t, rt.path, "/files/special")
+
+	rt, args = router.get("", "/files/special/file")
+	assert(t, rt.path, "/files/{path...}")
+	assert(t, args, []string{"special/file"})
+
+	rt, _ = router.get("", "/files")
+	assert(t, rt, (*route[*TestContainer, *TestUserData])(nil))
+
+	assert(t, cleanPlaceholders("/files/{path...}"), "/files/{path}")
+
+	_, _, err := parsePath("/files/{path...}/info")
+	assert(t, err != nil, true)
+
+	_, _, err = parsePath("/files/{path...}.txt")
+	assert(t, err != nil, true)
+}
+
+func TestRouterValidate(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	assert(t, router.Validate(), nil)
+
+	router.Add("/user/{id}", handler{})
+	router.Add("/user/{id:int}/info", handler{})
+	router.Add("/user/{id:[0-9]+}/info", handler{})
+
+	// different constraints which can not match the same value
+	router.Add("/item/{id:int}", handler{})
+	router.Add("/item/{uuid:uuid}", handler{})
+	router.Add("/item/{code:[a-z]+}.json", handler{})
+	router.Add("/item/{code:[a-z]+}.xml", handler{})
+
+	router.Add("/file/{id:int}.json", handler{})
+	router.Add("/file/{name}-{id:int}.json", handler{})
+	router.Add("/export/{name}.json", handler{})
+	router.Add("/export/{name}.xml", handler{})
+
+	router.Add("/doc/{id:int}.json", handler{})
+	router.Add("/doc/{id:uint}.json", handler{})
+	router.Add("/doc/{name}.{ext:[a-z]+}", handler{})
+	router.Add("/doc/{name}.{ext}", handler{})
+
+	router.Add("/user/{id}", handler{})
+	router.Add("/user/{name}", handler{})
+	router.Add("/user/{name", handler{})
+
+	err := router.AddE("/post/{id:(a|b)}", handler{})
+
+	var routeErr *RouteError
+	assert(t, errors.As(err, &routeErr), true)
+	assert(t, routeErr.Path, "/post/{id:(a|b)}")
+
+	err = router.Validate()
+
+	assert(t, err.Error(), "route [/user/{id}]: duplicate route; "+
+		"route [/user/{name}]: route is unreachable, it is shadowed by [/user/{id}]; "+
+		"route [/user/{name]: incorrect path segment [{name]: unclosed placeholder at position 0; "+
+		"route [/doc/{id:uint}.json]: path segment [{id:uint}.json] is ambiguous with [/doc/{id:int}.json]; "+
+		"route [/doc/{name}.{ext}]: path segment [{name}.{ext}] is ambiguous with [/doc/{name}.{ext:[a-z]+}]; "+
+		"route [/user/{id:[0-9]+}/info]: path segment [{id:[0-9]+}] is ambiguous with [/user/{id:int}/info]")
+}
+
+func TestRouterURL(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	sr := router.SubRoute("/api/v1")
+
+	sr.Add("/user/{user-id:int}", handler{Name: "user.get"})
+	sr.Add("/files/{path...}", handler{Name: "file.get"})
+	sr.Add("/search/{query}", handler{Name: "search"})
+
+	err := sr.AddE("/other", handler{Name: "search"})
+	assert(t, err.Error(), "route [/api/v1/other]: route name [search] is already used by [/api/v1/search/{query}]")
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		url    string
+		err    string
+	}{
+		{"user.get", map[string]string{"user-id": "42"}, "/api/v1/user/42", ""},
+		{"file.get", map[string]string{"path": "some dir/file.txt"}, "/api/v1/files/some%20dir/file.txt", ""},
+		{"search", map[string]string{"query": "a b?c#d"}, "/api/v1/search/a%20b%3Fc%23d", ""},
+		{"search", map[string]string{"query": "a/b"}, "", "value [a/b] of param [query] must not contain [/]"},
+		{"user.get", map[string]string{"user-id": "abc"}, "", "value [abc] of param [user-id] does not match the constraint [int]"},
+		{"user.get", map[string]string{}, "", "param [user-id] of route [user.get] is missing"},
+		{"user.get", map[string]string{"user-id": "42", "name": "test"}, "", "param [name] is not used by route [user.get]"},
+		{"unknown", nil, "", "route [unknown] not found"},
+	}
+
+	for _, tt := range tests {
+		res, err := router.URL(tt.name, tt.params)
+
+		if tt.err != "" {
+			if err == nil {
+				t.Errorf("error expected for route [%s]", tt.name)
+				continue
+			}
+
+			assert(t, err.Error(), tt.err)
+			continue
+		}
+
+		assert(t, err, nil)
+		assert(t, res, tt.url)
+
+		// the generated URL must be routed to the same route
+		rt, args, _ := router.match("", httptest.NewRequest(http.MethodGet, res, nil).URL.Path)
+		if rt == nil {
+			t.Errorf("route for URL [%s] not found", res)
+			continue
+		}
+
+		assert(t, rt.handler.Name, tt.name)
+
+		for i, name := range rt.args {
+			assert(t, args[i], tt.params[name])
+		}
+	}
+}
+
+func BenchmarkRouterLookup(b *testing.B) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	for i := 0; i < 500; i++ {
+		router.Add(fmt.Sprintf("/api/v1/resource-%d/{id}", i), handler{})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		router.get("", "/api/v1/resource-499/123")
+	}
+}
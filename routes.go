@@ -0,0 +1,171 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes the route added in to the router
+type RouteInfo struct {
+	Name        string       `json:"name,omitempty"`
+	Host        string       `json:"host,omitempty"`
+	Pattern     string       `json:"pattern"`
+	Args        []string     `json:"args,omitempty"`
+	Middlewares []string     `json:"middlewares,omitempty"`
+	Methods     []MethodInfo `json:"methods"`
+	Mounted     bool         `json:"mounted,omitempty"`
+}
+
+// MethodInfo describes the method handler of the route
+type MethodInfo struct {
+	Method       string `json:"method"`
+	Version      string `json:"version,omitempty"`
+	Request      string `json:"request,omitempty"`
+	Response     string `json:"response,omitempty"`
+	AuthOptional bool   `json:"authOptional"`
+}
+
+// closureSuffix matches names which are generated for anonymous functions like pkg.factory.func1
+var closureSuffix = regexp.MustCompile(`(\.func[0-9]+)(\.[0-9]+)*$`)
+
+// funcName returns the name of the function. The anonymous function returned by the factory like
+// func Logger(l Log) HandlerMiddleware is named by the factory.
+func funcName(f interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	name := fn.Name()
+
+	// dots in the package path are escaped, and escaped twice in names of anonymous functions
+	for strings.Contains(name, "%") {
+		unescaped, err := url.PathUnescape(name)
+		if err != nil {
+			break
+		}
+
+		name = unescaped
+	}
+
+	return closureSuffix.ReplaceAllString(name, "")
+}
+
+// Routes returns description of all routes in order of adding. Middlewares are listed in order of calling.
+func (r *Router[C, A]) Routes() []RouteInfo {
+	res := make([]RouteInfo, 0, len(r.routes))
+
+	for _, rt := range r.routes {
+		info := RouteInfo{
+			Name:    rt.handler.Name,
+			Pattern: rt.path,
+			Args:    rt.args,
+			Methods: []MethodInfo{},
+			Mounted: rt.handler.mount != nil,
+		}
+
+		if rt.host != nil {
+			info.Host = rt.host.pattern
+		}
+
+		// middlewares are listed in order of wrapping like in handleHttpRequest
+		for _, m := range rt.handler.Middlewares {
+			info.Middlewares = append(info.Middlewares, funcName(m))
+		}
+
+		for s := rt.scope; s != nil; s = s.parent {
+			for _, m := range s.middlewares {
+				info.Middlewares = append(info.Middlewares, funcName(m))
+			}
+		}
+
+		// each next middleware wraps the previous one so the last is called first
+		for i, j := 0, len(info.Middlewares)-1; i < j; i, j = i+1, j-1 {
+			info.Middlewares[i], info.Middlewares[j] = info.Middlewares[j], info.Middlewares[i]
+		}
+
+		handlers := OrderedMap[Handler[C, A]]{}
+		if rt.base {
+			handlers.Add("", rt.handler)
+		}
+
+		handlers = append(handlers, rt.versions...)
+
+		for _, h := range handlers {
+			for _, m := range h.value.handlers() {
+				info.Methods = append(info.Methods, MethodInfo{
+					Method:       m.name,
+					Version:      h.name,
+					Request:      m.value.description.requestObject.name,
+					Response:     m.value.description.responseObject.name,
+					AuthOptional: m.value.description.authOptional,
+				})
+			}
+		}
+
+		res = append(res, info)
+	}
+
+	return res
+}
+
+type textResponse struct {
+	*strings.Reader
+}
+
+func (textResponse) ContentType() string { return "text/plain; charset=utf-8" }
+
+func routesText(routes []RouteInfo) textResponse {
+	buf := &strings.Builder{}
+
+	w := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "METHOD\tVERSION\tHOST\tPATTERN\tNAME\tREQUEST\tRESPONSE\tAUTH\tMIDDLEWARES")
+
+	for _, rt := range routes {
+		if rt.Mounted {
+			_, _ = fmt.Fprintf(w, "*\t\t%s\t%s\t%s\t\t\t\t\n", rt.Host, rt.Pattern, rt.Name)
+		}
+
+		for _, m := range rt.Methods {
+			auth := "required"
+			if m.AuthOptional {
+				auth = "optional"
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				m.Method, m.Version, rt.Host, rt.Pattern, rt.Name, m.Request, m.Response, auth, strings.Join(rt.Middlewares, ", "))
+		}
+	}
+
+	_ = w.Flush()
+
+	return textResponse{strings.NewReader(buf.String())}
+}
+
+type routesRequest struct {
+	Format string `query:"format"`
+	Accept string `header:"Accept"`
+}
+
+// AddDebugRoutes adds the route which shows all routes of the router. The table is returned as JSON
+// or as plain text if it is requested by ?format=text or by Accept header.
+func (r *Router[C, A]) AddDebugRoutes(path string) {
+	r.Add(path, Handler[C, A]{
+		Get: Create(func(_ context.Context, _ C, _ A, rq *routesRequest) (interface{}, error) {
+			routes := r.Routes()
+
+			if rq.Format == "text" || rq.Format == "" && strings.HasPrefix(rq.Accept, "text/plain") {
+				return routesText(routes), nil
+			}
+
+			return routes, nil
+		}),
+	})
+}
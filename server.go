@@ -8,23 +8,17 @@ import (
 type Options struct {
 	SupportGZIP bool
 	Logger      Logger
+
+	// RequestErrorFormatter returns the response body for the request which can not be bound to
+	// the request object. The RequestError is written as is if it is not set. The response has
+	// the code of the RequestError unless the returned value implements ResponseWithCode.
+	RequestErrorFormatter func(*RequestError) interface{}
 }
 
 // NewServer creates and return new http server which the contains omg http handler
 func NewServer[C, A any](addr string, r Router[C, A], opt Options, middlewares ...RequestMiddleware[C, A]) *http.Server {
-	log := opt.Logger
-
-	if log == nil {
-		log = &emptyLogger{}
-	}
-
 	return &http.Server{
-		Addr: addr,
-		Handler: &HttpHandler[C, A]{
-			middlewares: middlewares,
-			router:      r,
-			log:         log,
-			gzip:        opt.SupportGZIP,
-		},
+		Addr:    addr,
+		Handler: NewHttpHandler(r, opt, middlewares...),
 	}
 }
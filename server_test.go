@@ -5,10 +5,13 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"runtime"
 	"strings"
@@ -141,9 +144,9 @@ func testRunner(t *testing.T, f func(context.Context, serverRunnerFunc, *http.Cl
 	l, client := newListenerAndClient()
 
 	var (
-		err    error
-		wg     sync.WaitGroup
-		server *http.Server
+		serveErr error
+		wg       sync.WaitGroup
+		server   *http.Server
 	)
 
 	serverRunner := func(s *http.Server) {
@@ -153,25 +156,25 @@ func testRunner(t *testing.T, f func(context.Context, serverRunnerFunc, *http.Cl
 		go func() {
 			defer wg.Done()
 
-			err = server.Serve(l)
+			serveErr = server.Serve(l)
 		}()
 	}
 
 	e := f(ctx, serverRunner, client)
 	if e != nil {
-		t.Fatalf("Error: [%v]", err)
+		t.Fatalf("Error: [%v]", e)
 	}
 
 	cancel()
-	err = server.Close()
+	err := server.Close()
 	if err != nil {
 		t.Fatalf("Error: [%v]", err)
 	}
 
 	wg.Wait()
 
-	if err != nil && err.Error() != "http: Server closed" {
-		t.Fatalf("Error: [%v]", err)
+	if serveErr != nil && serveErr.Error() != "http: Server closed" {
+		t.Fatalf("Error: [%v]", serveErr)
 	}
 }
 
@@ -274,6 +277,52 @@ func TestDefaultHandler(t *testing.T) {
 	})
 }
 
+func TestSubRouteDefaultHandler(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		defaultHandler := func(code int) handler {
+			return handler{
+				Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+					return nil, NewError(code, "default")
+				}),
+			}
+		}
+
+		api := router.SubRoute("/api/v1").Default(defaultHandler(http.StatusTeapot))
+		api.SubRoute("/admin").Default(defaultHandler(http.StatusForbidden))
+		router.SubRoute("/static").Default(defaultHandler(http.StatusGone))
+		router.Default(defaultHandler(http.StatusNotFound))
+
+		api.Add("/user", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, nil
+			}),
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		for path, status := range map[string]string{
+			"/api/v1/user":          "200 OK",
+			"/api/v1/unknown":       "418 I'm a teapot",
+			"/api/v1":               "418 I'm a teapot",
+			"/api/v1/admin/unknown": "403 Forbidden",
+			"/api/v1admin":          "404 Not Found",
+			"/static/app/route":     "410 Gone",
+			"/other":                "404 Not Found",
+		} {
+			resp, err := cl.Get("http://localhost" + path)
+			if err != nil {
+				return err
+			}
+
+			assert(t, resp.Status, status)
+		}
+
+		return nil
+	})
+}
+
 type TestUserDataWithArguments struct {
 	Args1 string `args:"args1"`
 	Args2 int    `args:"args2"`
@@ -349,6 +398,41 @@ func TestHandlerIntArgs(t *testing.T) {
 	})
 }
 
+type TestWildcardRequest struct {
+	Path     string   `args:"path"`
+	Segments []string `args:"path"`
+}
+
+func TestHandlerWildcardArgs(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		var request *TestWildcardRequest
+
+		router.Add("/files/{path...}", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestWildcardRequest) (*TestResponse, error) {
+				request = r
+
+				return nil, nil
+			}),
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		_, err := cl.Get("http://localhost/files/some/dir/file.txt")
+		if err != nil {
+			return err
+		}
+
+		assert(t, request, &TestWildcardRequest{
+			Path:     "some/dir/file.txt",
+			Segments: []string{"some", "dir", "file.txt"},
+		})
+
+		return nil
+	})
+}
+
 func TestNotFound(t *testing.T) {
 	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
 		router := NewRouter[*TestContainer, *TestUserData]()
@@ -406,7 +490,166 @@ func TestMethodNotSupported(t *testing.T) {
 			return err
 		}
 
-		assert(t, resp.Status, "404 Not Found")
+		assert(t, resp.Status, "405 Method Not Allowed")
+		assert(t, resp.Header.Get("Allow"), "POST, OPTIONS")
+
+		return nil
+	})
+}
+
+func TestCustomMethodNotAllowed(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		var allowed []string
+
+		router.Add("/test", handler{
+			Put:    Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) { return nil, nil }),
+			Delete: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) { return nil, nil }),
+		})
+
+		router.MethodNotAllowed(Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+			allowed = AllowedMethods(ctx)
+
+			return nil, NewError(http.StatusTeapot, "teapot")
+		}))
+
+		run(NewServer(":80", router, Options{}))
+
+		resp, err := cl.Get("http://localhost/test")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "418 I'm a teapot")
+		assert(t, resp.Header.Get("Allow"), "PUT, DELETE, OPTIONS")
+		assert(t, allowed, []string{http.MethodPut, http.MethodDelete, http.MethodOptions})
+
+		return nil
+	})
+}
+
+func TestHeadMethod(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.Add("/test", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return &TestResponse{Data: "test"}, nil
+			}),
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		resp, err := cl.Head("http://localhost/test")
+		if err != nil {
+			return err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "200 OK")
+		assert(t, resp.ContentLength, int64(len("{\"data\":\"test\"}\n")))
+		assert(t, len(data), 0)
+
+		return nil
+	})
+}
+
+func TestOptionsMethod(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.Add("/test", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, nil
+			}),
+			Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, nil
+			}),
+		})
+
+		router.Add("/custom", handler{
+			Options: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		req, _ := http.NewRequest(http.MethodOptions, "http://localhost/test", nil)
+
+		resp, err := cl.Do(req)
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "204 No Content")
+		assert(t, resp.Header.Get("Allow"), "GET, HEAD, POST, OPTIONS")
+
+		req, _ = http.NewRequest(http.MethodOptions, "http://localhost/custom", nil)
+
+		resp, err = cl.Do(req)
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "418 I'm a teapot")
+
+		return nil
+	})
+}
+
+func TestCustomMethod(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.Add("/test", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, nil
+			}),
+		}.On("PURGE", Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+			return nil, NewError(http.StatusTeapot, "teapot")
+		})))
+
+		router.AddSwagger("/swagger.json", SwaggerOpt{})
+
+		run(NewServer(":80", router, Options{}))
+
+		req, _ := http.NewRequest("PURGE", "http://localhost/test", nil)
+
+		resp, err := cl.Do(req)
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "418 I'm a teapot")
+
+		req, _ = http.NewRequest("REPORT", "http://localhost/test", nil)
+
+		resp, err = cl.Do(req)
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "405 Method Not Allowed")
+		assert(t, resp.Header.Get("Allow"), "GET, HEAD, PURGE, OPTIONS")
+
+		resp, err = cl.Get("http://localhost/swagger.json")
+		if err != nil {
+			return err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		assert(t, strings.Contains(string(data), `"/test":{"get":{`), true)
+		assert(t, strings.Contains(string(data), `"x-purge":{`), true)
 
 		return nil
 	})
@@ -451,6 +694,45 @@ func TestMethodNotSupported(t *testing.T) {
 //	})
 //}
 
+func TestCustomMethodNames(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	teapot := Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+		return nil, NewError(http.StatusTeapot, "teapot")
+	})
+
+	router.Add("/test", handler{
+		Methods: map[string]*MethodHandler[*TestContainer, *TestUserData]{
+			"get":   teapot,
+			"purge": teapot,
+		},
+	})
+
+	err := router.AddE("/other", handler{
+		Get: teapot,
+		Methods: map[string]*MethodHandler[*TestContainer, *TestUserData]{
+			"GET": teapot,
+		},
+	})
+
+	assert(t, err.Error(), "route [/other]: handler for method [GET] is set twice")
+
+	h := NewHttpHandler(router, Options{})
+
+	for _, method := range []string{http.MethodGet, "PURGE"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/test", nil))
+
+		assert(t, w.Code, http.StatusTeapot)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", nil))
+
+	assert(t, w.Code, http.StatusMethodNotAllowed)
+	assert(t, w.Header().Get("Allow"), "GET, HEAD, PURGE, OPTIONS")
+}
+
 func TestSubRoute(t *testing.T) {
 	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
 		router := NewRouter[*TestContainer, *TestUserData]()
@@ -474,89 +756,525 @@ func TestSubRoute(t *testing.T) {
 	})
 }
 
-func TestAllMethods(t *testing.T) {
+func TestSubRouteMiddlewares(t *testing.T) {
 	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
 		router := NewRouter[*TestContainer, *TestUserData]()
 
-		var methods []string
-
-		router.Add("/test", handler{
-			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
-				methods = append(methods, "GET")
-
-				return nil, NewError(http.StatusTeapot, "teapot")
-			}),
-
-			Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
-				methods = append(methods, "POST")
+		var calls []string
 
-				return nil, NewError(http.StatusTeapot, "teapot")
-			}),
+		middleware := func(name string) HandlerMiddleware[*TestContainer, *TestUserData] {
+			return func(h HandlerFunc[*TestContainer, *TestUserData]) HandlerFunc[*TestContainer, *TestUserData] {
+				return func(ctx context.Context, c *TestContainer, a *TestUserData, r *http.Request, argsPlace []string, args []string) interface{} {
+					calls = append(calls, name)
 
-			Put: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
-				methods = append(methods, "PUT")
+					return h(ctx, c, a, r, argsPlace, args)
+				}
+			}
+		}
 
-				return nil, NewError(http.StatusTeapot, "teapot")
-			}),
+		api := router.SubRoute("/api").Use(middleware("api"))
+		admin := api.SubRoute("/admin").Use(middleware("admin-1"), middleware("admin-2"))
 
-			Patch: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
-				methods = append(methods, "PATCH")
+		admin.Add("/user", handler{
+			Middlewares: []HandlerMiddleware[*TestContainer, *TestUserData]{middleware("handler")},
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				calls = append(calls, "user")
 
 				return nil, NewError(http.StatusTeapot, "teapot")
 			}),
+		})
 
-			Delete: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
-				methods = append(methods, "DELETE")
+		api.Add("/status", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				calls = append(calls, "status")
 
-				return nil, NewError(http.StatusTeapot, "teapot")
+				return nil, nil
 			}),
 		})
 
 		run(NewServer(":80", router, Options{}))
 
-		doRequest := func(method string, url string) {
-			req, err := http.NewRequest(method, url, nil)
-			if err != nil {
-				t.Fatal(err.Error())
-			}
-
-			resp, err := cl.Do(req)
-			if err != nil {
-				t.Fatal(err.Error())
-			}
-
-			if resp.Status != "418 I'm a teapot" {
-				t.Fail()
-			}
+		resp, err := cl.Get("http://localhost/api/admin/user")
+		if err != nil {
+			return err
 		}
 
-		doRequest(http.MethodGet, "http://localhost/test")
-		doRequest(http.MethodPost, "http://localhost/test")
-		doRequest(http.MethodPut, "http://localhost/test")
-		doRequest(http.MethodPatch, "http://localhost/test")
-		doRequest(http.MethodDelete, "http://localhost/test")
+		assert(t, resp.Status, "418 I'm a teapot")
+		assert(t, calls, []string{"api", "admin-2", "admin-1", "handler", "user"})
 
-		if !reflect.DeepEqual(methods, []string{"GET", "POST", "PUT", "PATCH", "DELETE"}) {
-			t.Fail()
+		calls = nil
+
+		_, err = cl.Get("http://localhost/api/status")
+		if err != nil {
+			return err
 		}
 
+		assert(t, calls, []string{"api", "status"})
+
 		return nil
 	})
 }
 
-//func TestSuccessResponse(t *testing.T) {
-//	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
-//		router := NewRouter[*TestContainer, *TestUserData]()
-//
-//		router.Add("/test", handler{
-//			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
-//				return &TestResponse{
-//					Data: "test",
-//				}, nil
-//			}),
-//		})
-//
-//		run(NewServer(ctx, ":80", router, Options{}))
+type TestTenantRequest struct {
+	Tenant string `args:"tenant"`
+	UserID int    `args:"user-id"`
+}
+
+func TestHostRouteSwagger(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/user/{user-id}", handler{
+		Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+			return nil, nil
+		}),
+	})
+
+	router.Host("admin.example.com").Add("/user/{user-id}", handler{
+		Delete: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+			return nil, nil
+		}),
+	})
+
+	router.AddSwagger("/swagger.json", SwaggerOpt{})
+
+	w := httptest.NewRecorder()
+	NewHttpHandler(router, Options{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	var swagger struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &swagger); err != nil {
+		t.Fatal(err)
+	}
+
+	methods := make([]string, 0)
+	for m := range swagger.Paths["/user/{user-id}"] {
+		methods = append(methods, m)
+	}
+
+	assert(t, len(swagger.Paths), 2)
+	assert(t, methods, []string{"get"})
+}
+
+func TestHostRoute(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		var request *TestTenantRequest
+
+		router.Host("{tenant}.example.com").SubRoute("/api").Add("/user/{user-id}", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestTenantRequest) (*TestResponse, error) {
+				request = r
+
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+		})
+
+		router.Host("admin.example.com").SubRoute("/api").Add("/user/{user-id}", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestTenantRequest) (*TestResponse, error) {
+				return nil, NewError(http.StatusForbidden, "forbidden")
+			}),
+		})
+
+		router.Add("/status", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, nil
+			}),
+		})
+
+		assert(t, router.Validate(), nil)
+
+		run(NewServer(":80", router, Options{}))
+
+		resp, err := cl.Get("http://acme.example.com:8080/api/user/42")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "418 I'm a teapot")
+		assert(t, request, &TestTenantRequest{Tenant: "acme", UserID: 42})
+
+		resp, err = cl.Get("http://admin.example.com/api/user/42")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "403 Forbidden")
+
+		resp, err = cl.Get("http://localhost/api/user/42")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "404 Not Found")
+
+		resp, err = cl.Get("http://acme.example.com/status")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "200 OK")
+
+		return nil
+	})
+}
+
+func testMiddleware(h HandlerFunc[*TestContainer, *TestUserData]) HandlerFunc[*TestContainer, *TestUserData] {
+	return h
+}
+
+func TestRoutes(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.SubRoute("/api").Use(testMiddleware).Add("/user/{user-id}", handler{
+			Name: "user",
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestUserDataWithArguments) (*TestResponse, error) {
+				return nil, nil
+			}, AuthOptional()),
+			Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, nil
+			}),
+		})
+
+		router.AddDebugRoutes("/debug/routes")
+
+		assert(t, router.Routes(), []RouteInfo{
+			{
+				Name:        "user",
+				Pattern:     "/api/user/{user-id}",
+				Args:        []string{"user-id"},
+				Middlewares: []string{funcName(testMiddleware)},
+				Methods: []MethodInfo{
+					{Method: http.MethodGet, Request: "TestUserDataWithArguments", Response: "TestResponse", AuthOptional: true},
+					{Method: http.MethodPost, Request: "TestRequest", Response: "TestResponse"},
+				},
+			},
+			{
+				Pattern: "/debug/routes",
+				Args:    []string{},
+				Methods: []MethodInfo{
+					{Method: http.MethodGet, Request: "routesRequest"},
+				},
+			},
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		resp, err := cl.Get("http://localhost/debug/routes?format=text")
+		if err != nil {
+			return err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Header.Get("Content-Type"), "text/plain; charset=utf-8")
+		assert(t, strings.Contains(string(data), "POST                   /api/user/{user-id}  user  TestRequest                TestResponse  required"), true)
+
+		resp, err = cl.Get("http://localhost/debug/routes")
+		if err != nil {
+			return err
+		}
+
+		data, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		assert(t, strings.HasPrefix(string(data), `[{"name":"user","pattern":"/api/user/{user-id}","args":["user-id"]`), true)
+
+		return nil
+	})
+}
+
+func namedMiddleware(name string, calls *[]string) HandlerMiddleware[*TestContainer, *TestUserData] {
+	return func(h HandlerFunc[*TestContainer, *TestUserData]) HandlerFunc[*TestContainer, *TestUserData] {
+		return func(ctx context.Context, c *TestContainer, a *TestUserData, r *http.Request, argsPlace []string, args []string) interface{} {
+			*calls = append(*calls, name)
+
+			return h(ctx, c, a, r, argsPlace, args)
+		}
+	}
+}
+
+func TestRoutesMiddlewaresOrder(t *testing.T) {
+	var calls []string
+
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	parent := router.SubRoute("/api").Use(namedMiddleware("parent", &calls))
+	child := parent.SubRoute("/v1").Use(testMiddleware)
+
+	child.Add("/user", handler{
+		Middlewares: []HandlerMiddleware[*TestContainer, *TestUserData]{namedMiddleware("handler-1", &calls), namedMiddleware("handler-2", &calls)},
+		Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+			calls = append(calls, "func")
+
+			return nil, nil
+		}),
+	})
+
+	w := httptest.NewRecorder()
+	NewHttpHandler(router, Options{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user", nil))
+
+	assert(t, calls, []string{"parent", "handler-2", "handler-1", "func"})
+
+	mw := "github.com/dedalqq/omg.httpserver.namedMiddleware"
+
+	assert(t, router.Routes()[0].Middlewares, []string{mw, funcName(testMiddleware), mw, mw})
+}
+
+func TestNormalizePath(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.Add("/api/user/{user-id}", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+		})
+
+		router.NormalizePath(PathOpt{
+			CleanPath:       true,
+			TrailingSlash:   true,
+			CaseInsensitive: true,
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		for _, p := range []string{"/api/user/42", "/api/user/42/", "/api//user/42", "/api/test/../user/42", "/API/User/42"} {
+			resp, err := cl.Get("http://localhost" + p)
+			if err != nil {
+				return err
+			}
+
+			assert(t, resp.Status, "418 I'm a teapot")
+		}
+
+		return nil
+	})
+}
+
+func TestNormalizePathRedirect(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.Add("/api/user/{user-id}", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+		})
+
+		router.NormalizePath(PathOpt{
+			CleanPath:     true,
+			TrailingSlash: true,
+			RedirectCode:  http.StatusPermanentRedirect,
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		cl.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+
+		resp, err := cl.Get("http://localhost/api//user/42/?a=1&b=2")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "308 Permanent Redirect")
+		assert(t, resp.Header.Get("Location"), "/api/user/42?a=1&b=2")
+
+		resp, err = cl.Get("http://localhost/API/user/42")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "404 Not Found")
+
+		return nil
+	})
+}
+
+func TestVersions(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		versionHandler := func(version string) handler {
+			return handler{
+				Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+					return &TestResponse{Data: version}, nil
+				}),
+			}
+		}
+
+		router.Versioning(VersionOpt{
+			Header:  "API-Version",
+			Vendor:  "acme",
+			Default: "v1",
+		})
+
+		router.AddVersion("/user", "v1", versionHandler("v1"))
+		router.AddVersion("/user", "v2", versionHandler("v2"))
+		router.Add("/status", versionHandler("base"))
+
+		router.AddSwagger("/swagger.json", SwaggerOpt{APIVersion: "v2"})
+
+		assert(t, router.AddVersion("/user", "v2", handler{}).Validate().Error(), "route [/user]: duplicate version [v2]")
+
+		run(NewServer(":80", router, Options{}))
+
+		tests := []struct {
+			header string
+			value  string
+			status string
+			body   string
+		}{
+			{"", "", "200 OK", `{"data":"v1"}`},
+			{"API-Version", "v2", "200 OK", `{"data":"v2"}`},
+			{"API-Version", "v3", "400 Bad Request", `{"code":400,"error":"unknown API version [v3]"}`},
+			{"Accept", "application/vnd.acme.v2+json", "200 OK", `{"data":"v2"}`},
+			{"Accept", "text/html, application/vnd.acme+json; version=v1", "200 OK", `{"data":"v1"}`},
+			{"Accept", "application/vnd.acme.v3+json", "406 Not Acceptable", `{"code":406,"error":"unknown API version [v3]"}`},
+			{"Accept", "application/vnd.acmecorp+json; version=v2", "200 OK", `{"data":"v1"}`},
+			{"Accept", "application/vnd.acmecorp.v2+json", "200 OK", `{"data":"v1"}`},
+		}
+
+		for _, tt := range tests {
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost/user", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			resp, err := cl.Do(req)
+			if err != nil {
+				return err
+			}
+
+			data, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+
+			assert(t, resp.Status, tt.status)
+			assert(t, strings.TrimSpace(string(data)), tt.body)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/status", nil)
+		req.Header.Set("API-Version", "v3")
+
+		resp, err := cl.Do(req)
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "200 OK")
+
+		resp, err = cl.Get("http://localhost/swagger.json")
+		if err != nil {
+			return err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		assert(t, strings.Contains(string(data), `"version":"v2"`), true)
+		assert(t, strings.Contains(string(data), `"/user":{"get"`), true)
+		assert(t, strings.Contains(string(data), `"/status":{"get"`), true)
+
+		return nil
+	})
+}
+
+func TestAllMethods(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		var methods []string
+
+		router.Add("/test", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				methods = append(methods, "GET")
+
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+
+			Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				methods = append(methods, "POST")
+
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+
+			Put: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				methods = append(methods, "PUT")
+
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+
+			Patch: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				methods = append(methods, "PATCH")
+
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+
+			Delete: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				methods = append(methods, "DELETE")
+
+				return nil, NewError(http.StatusTeapot, "teapot")
+			}),
+		})
+
+		run(NewServer(":80", router, Options{}))
+
+		doRequest := func(method string, url string) {
+			req, err := http.NewRequest(method, url, nil)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			resp, err := cl.Do(req)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			if resp.Status != "418 I'm a teapot" {
+				t.Fail()
+			}
+		}
+
+		doRequest(http.MethodGet, "http://localhost/test")
+		doRequest(http.MethodPost, "http://localhost/test")
+		doRequest(http.MethodPut, "http://localhost/test")
+		doRequest(http.MethodPatch, "http://localhost/test")
+		doRequest(http.MethodDelete, "http://localhost/test")
+
+		if !reflect.DeepEqual(methods, []string{"GET", "POST", "PUT", "PATCH", "DELETE"}) {
+			t.Fail()
+		}
+
+		return nil
+	})
+}
+
+//func TestSuccessResponse(t *testing.T) {
+//	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+//		router := NewRouter[*TestContainer, *TestUserData]()
+//
+//		router.Add("/test", handler{
+//			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+//				return &TestResponse{
+//					Data: "test",
+//				}, nil
+//			}),
+//		})
+//
+//		run(NewServer(ctx, ":80", router, Options{}))
 //
 //		req, err := http.NewRequest(http.MethodGet, "http://localhost/test", nil)
 //		if err != nil {
@@ -662,6 +1380,364 @@ func TestStdHandler(t *testing.T) {
 	})
 }
 
+func TestMount(t *testing.T) {
+	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		var paths []string
+
+		mounted := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			_, _ = fmt.Fprint(w, r.URL.Path)
+		})
+
+		router.Mount("/debug/pprof/", mounted)
+		router.SubRoute("/api").Mount("/files", mounted)
+
+		router.Add("/api/user", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				return nil, nil
+			}),
+		})
+
+		middleware := func(h RequestHandler[*TestContainer, *TestUserData]) RequestHandler[*TestContainer, *TestUserData] {
+			return func(ctx context.Context, rr Router[*TestContainer, *TestUserData], c *TestContainer, af AuthFunc[*TestUserData], w http.ResponseWriter, r *http.Request) (interface{}, bool) {
+				paths = append(paths, r.URL.Path)
+
+				return h(ctx, rr, c, af, w, r)
+			}
+		}
+
+		assert(t, router.Validate(), nil)
+
+		run(NewServer(":80", router, Options{}, middleware))
+
+		for path, expected := range map[string]string{
+			"/debug/pprof":         "/",
+			"/debug/pprof/":        "/",
+			"/debug/pprof/heap":    "/heap",
+			"/api/files/a/b/c.txt": "/a/b/c.txt",
+			"/api/files":           "/",
+		} {
+			resp, err := cl.Get("http://localhost" + path)
+			if err != nil {
+				return err
+			}
+
+			data, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+
+			assert(t, resp.Status, "418 I'm a teapot")
+			assert(t, string(data), expected)
+		}
+
+		resp, err := cl.Get("http://localhost/api/user")
+		if err != nil {
+			return err
+		}
+
+		assert(t, resp.Status, "200 OK")
+		assert(t, len(paths), 6)
+
+		return nil
+	})
+}
+
+func TestSetRouter(t *testing.T) {
+	newRouter := func(data string, wait chan struct{}) Router[*TestContainer, *TestUserData] {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.Add("/test", handler{
+			Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (*TestResponse, error) {
+				if wait != nil {
+					wait <- struct{}{}
+					<-wait
+				}
+
+				return &TestResponse{Data: data}, nil
+			}),
+		})
+
+		return router
+	}
+
+	wait := make(chan struct{})
+
+	h := NewHttpHandler(newRouter("old", wait), Options{})
+
+	done := make(chan string)
+
+	go func() {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		done <- w.Body.String()
+	}()
+
+	<-wait
+
+	h.SetRouter(newRouter("new", nil))
+
+	wait <- struct{}{}
+
+	assert(t, <-done, "{\"data\":\"old\"}\n")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+				if w.Code != http.StatusOK {
+					t.Errorf("unexpected status: %d", w.Code)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		h.SetRouter(newRouter(fmt.Sprintf("router-%d", i), nil))
+	}
+
+	wg.Wait()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	assert(t, w.Body.String(), "{\"data\":\"router-99\"}\n")
+}
+
+type bindingRequest struct {
+	ID    int    `args:"id"`
+	Limit int    `query:"limit"`
+	Trace bool   `header:"X-Trace"`
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+}
+
+func TestBindingError(t *testing.T) {
+	newHandler := func(opt Options) *HttpHandler[*TestContainer, *TestUserData] {
+		router := NewRouter[*TestContainer, *TestUserData]()
+
+		router.Add("/user/{id}", handler{
+			Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *bindingRequest) (*TestResponse, error) {
+				return &TestResponse{Data: fmt.Sprintf("%d %d %v %s %d", r.ID, r.Limit, r.Trace, r.Name, r.Age)}, nil
+			}),
+		})
+
+		return NewHttpHandler(router, opt)
+	}
+
+	h := newHandler(Options{})
+
+	do := func(path string, header http.Header, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header = header
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	w := do("/user/1?limit=2", http.Header{"Content-Type": {"application/json"}, "X-Trace": {"1"}}, `{"name":"bob","age":3}`)
+	assert(t, w.Code, http.StatusOK)
+	assert(t, w.Body.String(), "{\"data\":\"1 2 true bob 3\"}\n")
+
+	w = do("/user/x", jsonHeader, "")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"id","location":"args","code":"invalid","message":"value [x] must be int"}]}`+"\n")
+
+	w = do("/user/1?limit=a", jsonHeader, "")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"limit","location":"query","code":"invalid","message":"value [a] must be int"}]}`+"\n")
+
+	w = do("/user/1", http.Header{"Content-Type": {"application/json"}, "X-Trace": {"yes"}}, "")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"X-Trace","location":"header","code":"invalid","message":"value [yes] must be bool"}]}`+"\n")
+
+	w = do("/user/1", jsonHeader, `{"age":"old"}`)
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"age","location":"body","code":"invalid","message":"value must be int"}]}`+"\n")
+
+	w = do("/user/1", jsonHeader, `{"name":`)
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"location":"body","code":"malformed","message":"unexpected EOF"}]}`+"\n")
+
+	h = newHandler(Options{
+		RequestErrorFormatter: func(err *RequestError) interface{} {
+			return err.Errors[0].Error()
+		},
+	})
+
+	w = do("/user/1?limit=a", jsonHeader, "")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), "query [limit]: value [a] must be int")
+
+	h = newHandler(Options{
+		RequestErrorFormatter: func(err *RequestError) interface{} {
+			return errors.New(err.Errors[0].Message)
+		},
+	})
+
+	w = do("/user/1?limit=a", jsonHeader, "")
+	assert(t, w.Code, http.StatusBadRequest)
+
+	h = newHandler(Options{
+		RequestErrorFormatter: func(err *RequestError) interface{} {
+			return NewError(http.StatusUnprocessableEntity, "%s", err.Errors[0].Message)
+		},
+	})
+
+	w = do("/user/1?limit=a", jsonHeader, "")
+	assert(t, w.Code, http.StatusUnprocessableEntity)
+	assert(t, w.Body.String(), `{"code":422,"error":"value [a] must be int"}`+"\n")
+}
+
+func TestBindingErrors(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/user/{id}", handler{
+		Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *bindingRequest) (*TestResponse, error) {
+			return nil, nil
+		}),
+	})
+
+	var fields []string
+
+	h := NewHttpHandler(router, Options{}, func(h RequestHandler[*TestContainer, *TestUserData]) RequestHandler[*TestContainer, *TestUserData] {
+		return func(ctx context.Context, rr Router[*TestContainer, *TestUserData], c *TestContainer, af AuthFunc[*TestUserData], w http.ResponseWriter, r *http.Request) (interface{}, bool) {
+			result, ctn := h(ctx, rr, c, af, w, r)
+
+			var reqErr *RequestError
+			if err, ok := result.(error); ok && errors.As(err, &reqErr) {
+				for _, e := range reqErr.Errors {
+					fields = append(fields, e.Location+":"+e.Field)
+				}
+			}
+
+			return result, ctn
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/user/x?limit=a", strings.NewReader(`{"age":"old"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Trace", "yes")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, fields, []string{"body:age", "args:id", "query:limit", "header:X-Trace"})
+}
+
+func TestContentType(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/user/{id}", handler{
+		Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *bindingRequest) (*TestResponse, error) {
+			return &TestResponse{Data: r.Name}, nil
+		}),
+	})
+
+	h := NewHttpHandler(router, Options{})
+
+	for contentType, expected := range map[string]string{
+		"application/json":                "{\"data\":\"bob\"}\n",
+		"application/json; charset=utf-8": "{\"data\":\"bob\"}\n",
+		"Application/JSON":                "{\"data\":\"bob\"}\n",
+		"application/merge-patch+json":    "{\"data\":\"bob\"}\n",
+		"text/plain":                      `{"code":415,"error":"unsupported media type [text/plain], accepted types: application/json, application/*+json, application/x-www-form-urlencoded, multipart/form-data"}` + "\n",
+		"application/json; charset=\"utf": `{"code":415,"error":"unsupported media type [application/json; charset=\"utf], accepted types: application/json, application/*+json, application/x-www-form-urlencoded, multipart/form-data"}` + "\n",
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/user/1", strings.NewReader(`{"name":"bob"}`))
+		r.Header.Set("Content-Type", contentType)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert(t, w.Body.String(), expected)
+	}
+}
+
+type queryRequest struct {
+	Tags   []string `query:"tag"`
+	IDs    []int    `query:"ids,csv" validate:"max=3"`
+	Names  []string `query:"names,pipes"`
+	Point  [2]int   `query:"point,csv"`
+	Limit  int      `query:"limit"`
+	Filter *string  `query:"filter"`
+}
+
+func TestQuerySlices(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/search", handler{
+		Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *queryRequest) (*TestResponse, error) {
+			return &TestResponse{Data: fmt.Sprintf("%q %v %q %v %d %v", r.Tags, r.IDs, r.Names, r.Point, r.Limit, r.Filter != nil)}, nil
+		}),
+	})
+
+	router.AddSwagger("/swagger.json", SwaggerOpt{})
+
+	h := NewHttpHandler(router, Options{})
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return w
+	}
+
+	w := do("/search?tag=a&tag=b&ids=1,2&ids=3&names=x|y&point=4,5&limit=7&limit=8")
+	assert(t, w.Code, http.StatusOK)
+	assert(t, w.Body.String(), `{"data":"[\"a\" \"b\"] [1 2 3] [\"x\" \"y\"] [4 5] 7 false"}`+"\n")
+
+	w = do("/search?filter=abc")
+	assert(t, w.Body.String(), `{"data":"[] [] [] [0 0] 0 true"}`+"\n")
+
+	w = do("/search?ids=1,a&point=1,2,3&ids=5,6")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"ids","location":"query","code":"invalid","message":"value [a] must be int"},{"field":"point","location":"query","code":"invalid","message":"too many values, maximum is 2"}]}`+"\n")
+
+	w = do("/search?ids=1,2,3,4")
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"ids","location":"query","code":"max","message":"length must be at most 3"}]}`+"\n")
+
+	w = do("/swagger.json")
+
+	for _, s := range []string{
+		`{"in":"query","name":"tag","type":"array","items":{"type":"string"},"collectionFormat":"multi"}`,
+		`{"in":"query","name":"ids","type":"array","maxItems":3,"items":{"type":"integer","format":"int64"},"collectionFormat":"csv"}`,
+		`{"in":"query","name":"names","type":"array","items":{"type":"string"},"collectionFormat":"pipes"}`,
+		`{"in":"query","name":"limit","type":"integer","format":"int64"}`,
+	} {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("swagger does not contain %s", s)
+		}
+	}
+
+	defer func() {
+		assert(t, recover(), "incorrect tags of the request [badQuery]: field [badQuery.IDs]: unknown collection format [tsv]")
+	}()
+
+	type badQuery struct {
+		IDs []int `query:"ids,tsv"`
+	}
+
+	Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *badQuery) (*TestResponse, error) {
+		return nil, nil
+	})
+}
+
 func TestAuth(t *testing.T) {
 	testRunner(t, func(ctx context.Context, run serverRunnerFunc, cl *http.Client) error {
 		router := NewRouter[*TestContainer, *TestUserData]()
@@ -0,0 +1,287 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOpt is options of the static files server
+type StaticOpt struct {
+	// Index is a file which is served for a directory, by default index.html
+	Index string
+
+	// Browse enables the listing of directories without the index file
+	Browse bool
+
+	// SPAFallback is a file which is served if the requested file is not found,
+	// for example index.html of the single page application
+	SPAFallback string
+
+	// Precompressed enables serving of .br and .gz files which are placed near the requested file
+	// if the client accepts the encoding
+	Precompressed bool
+
+	// CacheControl is a value of Cache-Control header of the response
+	CacheControl string
+}
+
+type fileServer struct {
+	fsys fs.FS
+	opt  StaticOpt
+
+	// etags contains hashes of files without modification time
+	etags sync.Map
+}
+
+// NewFileServer returns the handler which serves files from the file system. It supports Range requests,
+// conditional requests by ETag and Last-Modified and precompressed files.
+func NewFileServer(fsys fs.FS, opt StaticOpt) http.Handler {
+	if opt.Index == "" {
+		opt.Index = "index.html"
+	}
+
+	return &fileServer{
+		fsys: fsys,
+		opt:  opt,
+	}
+}
+
+// Static mounts the server of files from the file system under the path prefix. The request
+// for the prefix without the trailing slash is redirected to the prefix with the slash.
+func (r *Router[C, A]) Static(prefix string, fsys fs.FS, opt StaticOpt) *Router[C, A] {
+	return r.mount(prefix, Handler[C, A]{mount: NewFileServer(fsys, opt), mountDir: true})
+}
+
+// Static mounts the server of files from the file system under the path prefix in the sub router
+func (r *SubRouter[C, A]) Static(prefix string, fsys fs.FS, opt StaticOpt) *SubRouter[C, A] {
+	return r.mount(prefix, Handler[C, A]{mount: NewFileServer(fsys, opt), mountDir: true})
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if errors.Is(err, fs.ErrNotExist) && s.opt.SPAFallback != "" {
+		name = s.opt.SPAFallback
+		info, err = fs.Stat(s.fsys, name)
+	}
+
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	if !info.IsDir() {
+		s.serveFile(w, r, name)
+		return
+	}
+
+	// relative links of the directory page require the trailing slash. The redirect is relative
+	// because the handler does not know the prefix which was stripped from the path.
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		u := url.URL{Path: path.Base(r.URL.Path) + "/", RawQuery: r.URL.RawQuery}
+
+		w.Header().Set("Location", u.String())
+		w.WriteHeader(http.StatusMovedPermanently)
+
+		return
+	}
+
+	index := path.Join(name, s.opt.Index)
+	if _, err = fs.Stat(s.fsys, index); err == nil {
+		s.serveFile(w, r, index)
+		return
+	}
+
+	if !s.opt.Browse {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	s.serveDir(w, r, name)
+}
+
+func (s *fileServer) error(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// encodings are extensions of precompressed files in order of preference
+var encodings = []struct {
+	name, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+func acceptEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(name) != encoding {
+			continue
+		}
+
+		// the encoding is not acceptable if its quality is zero
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+
+	return false
+}
+
+func (s *fileServer) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	fileName := name
+	encoding := ""
+
+	if s.opt.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		for _, e := range encodings {
+			if !acceptEncoding(r.Header.Get("Accept-Encoding"), e.name) {
+				continue
+			}
+
+			if info, err := fs.Stat(s.fsys, name+e.ext); err == nil && !info.IsDir() {
+				fileName, encoding = name+e.ext, e.name
+				break
+			}
+		}
+	}
+
+	f, err := s.fsys.Open(fileName)
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			s.error(w, err)
+			return
+		}
+
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := s.etag(fileName, info, content)
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+
+	if s.opt.CacheControl != "" {
+		w.Header().Set("Cache-Control", s.opt.CacheControl)
+	}
+
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+
+		// the content type is detected by the original file because the compressed content can not be sniffed
+		ct := mime.TypeByExtension(path.Ext(name))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+
+		w.Header().Set("Content-Type", ct)
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// etag returns the entity tag of the file by its modification time or by hash of the content
+// if the modification time is unknown, for example for embed.FS
+func (s *fileServer) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+
+	h := sha256.New()
+
+	_, err := io.Copy(h, content)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = content.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	etag := fmt.Sprintf(`"%x"`, h.Sum(nil)[:16])
+	s.etags.Store(name, etag)
+
+	return etag, nil
+}
+
+func (s *fileServer) serveDir(w http.ResponseWriter, r *http.Request, name string) {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	buf := &bytes.Buffer{}
+
+	buf.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() {
+			n += "/"
+		}
+
+		u := url.URL{Path: n}
+		_, _ = fmt.Fprintf(buf, "<a href=\"%s\">%s</a>\n", html.EscapeString(u.String()), html.EscapeString(n))
+	}
+
+	buf.WriteString("</pre>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
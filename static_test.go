@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStatic(t *testing.T) {
+	modTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsys := fstest.MapFS{
+		"index.html":       {Data: []byte("<html>index</html>")},
+		"app.js":           {Data: []byte("console.log('app')")},
+		"app.js.br":        {Data: []byte("brotli")},
+		"app.js.gz":        {Data: []byte("gzip")},
+		"style.css":        {Data: []byte("body {}"), ModTime: modTime},
+		"docs/readme.txt":  {Data: []byte("readme")},
+		"docs/guide/a.txt": {Data: []byte("a")},
+	}
+
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Static("/static", fsys, StaticOpt{Precompressed: true, CacheControl: "max-age=60"})
+	router.SubRoute("/browse").Static("/", fsys, StaticOpt{Browse: true})
+	router.Static("/app", fsys, StaticOpt{SPAFallback: "index.html"})
+
+	h := NewHttpHandler(router, Options{})
+
+	do := func(path string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := do("/static/app.js")
+	assert(t, w.Code, http.StatusOK)
+	assert(t, w.Body.String(), "console.log('app')")
+	assert(t, w.Header().Get("Content-Type"), "text/javascript; charset=utf-8")
+	assert(t, w.Header().Get("Cache-Control"), "max-age=60")
+
+	etag := w.Header().Get("ETag")
+	assert(t, etag != "", true)
+
+	w = do("/static/app.js", "If-None-Match", etag)
+	assert(t, w.Code, http.StatusNotModified)
+
+	w = do("/static/app.js", "Range", "bytes=0-6")
+	assert(t, w.Code, http.StatusPartialContent)
+	assert(t, w.Body.String(), "console")
+
+	w = do("/static/style.css")
+	assert(t, w.Header().Get("Last-Modified"), modTime.Format(http.TimeFormat))
+
+	w = do("/static/style.css", "If-Modified-Since", modTime.Format(http.TimeFormat))
+	assert(t, w.Code, http.StatusNotModified)
+
+	w = do("/static/app.js", "Accept-Encoding", "gzip, br")
+	assert(t, w.Body.String(), "brotli")
+	assert(t, w.Header().Get("Content-Encoding"), "br")
+	assert(t, w.Header().Get("Content-Type"), "text/javascript; charset=utf-8")
+	assert(t, w.Header().Get("Vary"), "Accept-Encoding")
+
+	w = do("/static/app.js", "Accept-Encoding", "gzip, br;q=0")
+	assert(t, w.Body.String(), "gzip")
+	assert(t, w.Header().Get("Content-Encoding"), "gzip")
+
+	w = do("/static/")
+	assert(t, w.Body.String(), "<html>index</html>")
+
+	w = do("/static/docs/")
+	assert(t, w.Code, http.StatusNotFound)
+
+	w = do("/static/docs")
+	assert(t, w.Code, http.StatusMovedPermanently)
+	assert(t, w.Header().Get("Location"), "docs/")
+
+	w = do("/static?v=1")
+	assert(t, w.Code, http.StatusMovedPermanently)
+	assert(t, w.Header().Get("Location"), "/static/?v=1")
+
+	w = do("/browse")
+	assert(t, w.Code, http.StatusMovedPermanently)
+	assert(t, w.Header().Get("Location"), "/browse/")
+
+	w = do("/static/unknown.js")
+	assert(t, w.Code, http.StatusNotFound)
+
+	w = do("/browse/docs/")
+	assert(t, w.Code, http.StatusOK)
+	assert(t, strings.Contains(w.Body.String(), `<a href="guide/">guide/</a>`), true)
+	assert(t, strings.Contains(w.Body.String(), `<a href="readme.txt">readme.txt</a>`), true)
+
+	w = do("/app/some/client/route")
+	assert(t, w.Code, http.StatusOK)
+	assert(t, w.Body.String(), "<html>index</html>")
+
+	r := httptest.NewRequest(http.MethodPost, "/static/app.js", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert(t, w.Code, http.StatusMethodNotAllowed)
+}
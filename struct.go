@@ -37,12 +37,19 @@ type apiDescription struct {
 	headers OrderedMap[apiType]
 	args    OrderedMap[apiType]
 	query   OrderedMap[apiType]
+	form    OrderedMap[apiType]
+
+	rules *structRules
+
+	// limits of the multipart form
+	maxMemory, maxDisk int64
 
 	requestObject objectType
 
-	respContentType   string
-	successStatusCode int
-	responseObject    objectType
+	respContentType     string
+	successStatusCode   int
+	validationErrorCode int
+	responseObject      objectType
 }
 
 type MethodHandler[C, A any] struct {
@@ -51,6 +58,9 @@ type MethodHandler[C, A any] struct {
 }
 
 type Handler[C, A any] struct {
+	// Name of the route which can be used for build URL by Router.URL
+	Name string
+
 	StdHandler StdHandler
 
 	Middlewares []HandlerMiddleware[C, A]
@@ -60,6 +70,21 @@ type Handler[C, A any] struct {
 	Put    *MethodHandler[C, A]
 	Delete *MethodHandler[C, A]
 	Patch  *MethodHandler[C, A]
+
+	// Head and Options override handlers which are used by default. The HEAD request is handled
+	// by Get handler without response body and the OPTIONS request returns allowed methods.
+	Head    *MethodHandler[C, A]
+	Options *MethodHandler[C, A]
+
+	// Methods contains handlers for non-standard HTTP methods like PURGE or REPORT. Names are converted to
+	// upper case and handlers of standard methods are used like the corresponding fields.
+	Methods map[string]*MethodHandler[C, A]
+
+	mount http.Handler
+
+	// mountDir redirects the request for the mount prefix to the prefix with the trailing slash
+	// because relative links of the mounted pages must be resolved inside the prefix
+	mountDir bool
 }
 
 type ResponseWithCode interface {
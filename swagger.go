@@ -5,16 +5,32 @@ const (
 	TypeArray   = "array"
 	TypeString  = "string"
 	TypeInteger = "integer"
+	TypeNumber  = "number"
 	TypeBool    = "boolean"
+	TypeFile    = "file"
 )
 
 type apiType struct {
 	Type        string              `json:"type,omitempty"`
 	Description string              `json:"description,omitempty"`
-	Required    bool                `json:"required,omitempty"`
+	Required    bool                `json:"-"`
 	Format      string              `json:"format,omitempty"`
+	Pattern     string              `json:"pattern,omitempty"`
+	Minimum     *float64            `json:"minimum,omitempty"`
+	Maximum     *float64            `json:"maximum,omitempty"`
+	MinLength   *int                `json:"minLength,omitempty"`
+	MaxLength   *int                `json:"maxLength,omitempty"`
+	MinItems    *int                `json:"minItems,omitempty"`
+	MaxItems    *int                `json:"maxItems,omitempty"`
+	Enum        []interface{}       `json:"enum,omitempty"`
 	Items       *apiType            `json:"items,omitempty"`
 	Properties  OrderedMap[apiType] `json:"properties,omitempty"`
+
+	// RequiredProperties contains names of the required properties of the object
+	RequiredProperties []string `json:"required,omitempty"`
+
+	// CollectionFormat is used only for array parameters
+	CollectionFormat string `json:"-"`
 }
 
 type apiInfo struct {
@@ -29,27 +45,36 @@ type apiTags struct {
 type apiSecurity struct {
 }
 
+// apiSchema is a reference to the definition or the inline schema of the type which is not an object
 type apiSchema struct {
-	Ref string `json:"$ref"`
+	Ref string `json:"$ref,omitempty"`
+	apiType
 }
 
 type apiParameter struct {
-	In          string     `json:"in,omitempty"`
-	Name        string     `json:"name,omitempty"`
-	Type        string     `json:"type,omitempty"`
-	Description string     `json:"description,omitempty"`
-	Required    bool       `json:"required,omitempty"`
-	Schema      *apiSchema `json:"schema,omitempty"`
-}
+	In          string        `json:"in,omitempty"`
+	Name        string        `json:"name,omitempty"`
+	Type        string        `json:"type,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Pattern     string        `json:"pattern,omitempty"`
+	Minimum     *float64      `json:"minimum,omitempty"`
+	Maximum     *float64      `json:"maximum,omitempty"`
+	MinLength   *int          `json:"minLength,omitempty"`
+	MaxLength   *int          `json:"maxLength,omitempty"`
+	MinItems    *int          `json:"minItems,omitempty"`
+	MaxItems    *int          `json:"maxItems,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Items       *apiType      `json:"items,omitempty"`
+	Schema      *apiSchema    `json:"schema,omitempty"`
 
-type apiEndpoint struct {
-	Get    *apiHandler `json:"get,omitempty"`
-	Post   *apiHandler `json:"post,omitempty"`
-	Put    *apiHandler `json:"put,omitempty"`
-	Delete *apiHandler `json:"delete,omitempty"`
-	Patch  *apiHandler `json:"patch,omitempty"`
+	CollectionFormat string `json:"collectionFormat,omitempty"`
 }
 
+// apiEndpoint is a path item which contains operations by method names
+type apiEndpoint = OrderedMap[*apiHandler]
+
 type apiHandler struct {
 	Tags        []string                 `json:"tags,omitempty"`
 	Summary     string                   `json:"summary,omitempty"`
@@ -0,0 +1,543 @@
+package httpserver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentStatic segmentKind = iota
+	segmentPattern
+	segmentParam
+	segmentWildcard
+)
+
+// mountSegment matches the rest of the path for mounted handlers
+var mountSegment = segment{kind: segmentWildcard, key: "{...}", raw: "{...}"}
+
+// segment is a one part of the route path between two slashes
+type segment struct {
+	kind    segmentKind
+	key     string
+	raw     string
+	pattern *regexp.Regexp
+	params  []placeholder
+}
+
+func (s segment) match(value string, args []string) ([]string, bool) {
+	switch s.kind {
+	case segmentParam:
+		if value == "" {
+			return args, false
+		}
+
+		return append(args, value), true
+	case segmentPattern:
+		res := s.pattern.FindStringSubmatch(value)
+		if len(res) == 0 {
+			return args, false
+		}
+
+		return append(args, res[1:]...), true
+	default:
+		return args, value == s.key
+	}
+}
+
+// placeholders returns positions of all placeholders in the string
+func placeholders(s string) ([][2]int, error) {
+	var (
+		res   [][2]int
+		start = -1
+		depth = 0
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("unexpected '}' at position %d", i)
+			}
+
+			depth--
+
+			if depth == 0 {
+				if i-start == 1 {
+					return nil, fmt.Errorf("empty placeholder at position %d", start)
+				}
+
+				res = append(res, [2]int{start, i + 1})
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("unclosed placeholder at position %d", start)
+	}
+
+	return res, nil
+}
+
+// placeholder is a named part of the path which value is passed to the handler as an argument
+type placeholder struct {
+	name       string
+	constraint string
+	wildcard   bool
+}
+
+// constraints contains named constraints which can be used in placeholders like {id:int}
+var constraints = map[string]string{
+	"int":  `-?[0-9]+`,
+	"uint": `[0-9]+`,
+	"uuid": `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// overlappingConstraints contains pairs of the named constraints which can match the same value
+var overlappingConstraints = [][2]string{
+	{"int", "uint"},
+}
+
+// constraintExpr returns the regexp of the constraint
+func constraintExpr(constraint string) string {
+	if expr, ok := constraints[constraint]; ok {
+		return expr
+	}
+
+	return constraint
+}
+
+// constraintsOverlap returns true if both constraints are known to match the same value
+func constraintsOverlap(a, b string) bool {
+	a, b = constraintExpr(a), constraintExpr(b)
+
+	if a == b {
+		return true
+	}
+
+	for _, pair := range overlappingConstraints {
+		x, y := constraintExpr(pair[0]), constraintExpr(pair[1])
+
+		if a == x && b == y || a == y && b == x {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skeleton returns the segment where all placeholders are replaced by {}
+func skeleton(s string) string {
+	pos, err := placeholders(s)
+	if err != nil {
+		return s
+	}
+
+	var (
+		res  strings.Builder
+		last = 0
+	)
+
+	for _, p := range pos {
+		res.WriteString(s[last:p[0]] + "{}")
+		last = p[1]
+	}
+
+	res.WriteString(s[last:])
+
+	return res.String()
+}
+
+// overlaps returns true if both pattern segments can match the same value. Patterns overlap if they have
+// the same static parts and each pair of placeholders is unconstrained or has constraints which can match
+// the same values.
+func (s segment) overlaps(o segment) bool {
+	if s.key == o.key {
+		return true
+	}
+
+	if skeleton(s.raw) != skeleton(o.raw) || len(s.params) != len(o.params) {
+		return false
+	}
+
+	for _, p := range append(s.params[:len(s.params):len(s.params)], o.params...) {
+		if p.constraint == "" {
+			return true
+		}
+	}
+
+	for i := range s.params {
+		if !constraintsOverlap(s.params[i].constraint, o.params[i].constraint) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parsePlaceholder(s string) (placeholder, string, error) {
+	name, constraint, _ := strings.Cut(s, ":")
+
+	wildcard := strings.HasSuffix(name, "...")
+	name = strings.TrimSuffix(name, "...")
+
+	if name == "" {
+		return placeholder{}, "", fmt.Errorf("placeholder [%s] does not have a name", s)
+	}
+
+	if wildcard && constraint != "" {
+		return placeholder{}, "", fmt.Errorf("wildcard placeholder [%s] can not have a constraint", name)
+	}
+
+	p := placeholder{
+		name:       name,
+		constraint: constraint,
+		wildcard:   wildcard,
+	}
+
+	if constraint == "" {
+		return p, "", nil
+	}
+
+	expr, ok := constraints[constraint]
+	if !ok {
+		expr = constraint
+	}
+
+	reg, err := regexp.Compile(expr)
+	if err != nil {
+		return placeholder{}, "", fmt.Errorf("incorrect constraint of placeholder [%s]: %w", name, err)
+	}
+
+	if reg.NumSubexp() > 0 {
+		return placeholder{}, "", fmt.Errorf("constraint of placeholder [%s] must not contain capturing groups, use (?:...) instead", name)
+	}
+
+	return p, expr, nil
+}
+
+func parseSegment(s string) (segment, []placeholder, error) {
+	pos, err := placeholders(s)
+	if err != nil {
+		return segment{}, nil, err
+	}
+
+	if len(pos) == 0 {
+		return segment{kind: segmentStatic, key: s, raw: s}, nil, nil
+	}
+
+	var (
+		expr   strings.Builder
+		params = make([]placeholder, 0, len(pos))
+		last   = 0
+	)
+
+	expr.WriteByte('^')
+
+	for _, p := range pos {
+		param, constraint, err := parsePlaceholder(s[p[0]+1 : p[1]-1])
+		if err != nil {
+			return segment{}, nil, err
+		}
+
+		if param.wildcard && (len(pos) > 1 || p[0] != 0 || p[1] != len(s)) {
+			return segment{}, nil, fmt.Errorf("wildcard placeholder [%s] must be a whole segment", param.name)
+		}
+
+		if constraint == "" {
+			constraint = "[^/]+"
+		}
+
+		expr.WriteString(regexp.QuoteMeta(s[last:p[0]]))
+		expr.WriteString("(" + constraint + ")")
+
+		params = append(params, param)
+		last = p[1]
+	}
+
+	expr.WriteString(regexp.QuoteMeta(s[last:]))
+	expr.WriteByte('$')
+
+	if params[0].wildcard {
+		return segment{kind: segmentWildcard, key: "{...}", raw: s}, params, nil
+	}
+
+	if len(pos) == 1 && pos[0][0] == 0 && pos[0][1] == len(s) && params[0].constraint == "" {
+		return segment{kind: segmentParam, key: "{}", raw: s}, params, nil
+	}
+
+	reg, err := regexp.Compile(expr.String())
+	if err != nil {
+		return segment{}, nil, err
+	}
+
+	return segment{kind: segmentPattern, key: reg.String(), raw: s, pattern: reg, params: params}, params, nil
+}
+
+// parsePath splits the route path to segments and returns it with all placeholders
+func parsePath(path string) ([]segment, []placeholder, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	segments := make([]segment, 0, len(parts))
+	params := make([]placeholder, 0)
+
+	for i, p := range parts {
+		s, a, err := parseSegment(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("incorrect path segment [%s]: %w", p, err)
+		}
+
+		if s.kind == segmentWildcard && i != len(parts)-1 {
+			return nil, nil, fmt.Errorf("wildcard segment [%s] must be the last one", p)
+		}
+
+		segments = append(segments, s)
+		params = append(params, a...)
+	}
+
+	return segments, params, nil
+}
+
+// parseHost compiles the host pattern to the regexp and returns it with all placeholders
+func parseHost(host string) (*regexp.Regexp, []placeholder, error) {
+	pos, err := placeholders(host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		expr   strings.Builder
+		params = make([]placeholder, 0, len(pos))
+		last   = 0
+	)
+
+	expr.WriteString("^(?i)")
+
+	for _, p := range pos {
+		param, constraint, err := parsePlaceholder(host[p[0]+1 : p[1]-1])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if param.wildcard {
+			return nil, nil, fmt.Errorf("wildcard placeholder [%s] is not supported in host", param.name)
+		}
+
+		if constraint == "" {
+			constraint = "[^.]+"
+		}
+
+		expr.WriteString(regexp.QuoteMeta(host[last:p[0]]))
+		expr.WriteString("(" + constraint + ")")
+
+		params = append(params, param)
+		last = p[1]
+	}
+
+	expr.WriteString(regexp.QuoteMeta(host[last:]))
+	expr.WriteByte('$')
+
+	reg, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reg, params, nil
+}
+
+// cleanPlaceholders removes constraints from placeholders of the path
+func cleanPlaceholders(path string) string {
+	pos, err := placeholders(path)
+	if err != nil {
+		return path
+	}
+
+	var (
+		res  strings.Builder
+		last = 0
+	)
+
+	for _, p := range pos {
+		name, _, _ := strings.Cut(path[p[0]+1:p[1]-1], ":")
+		name = strings.TrimSuffix(name, "...")
+
+		res.WriteString(path[last:p[0]])
+		res.WriteString("{" + name + "}")
+
+		last = p[1]
+	}
+
+	res.WriteString(path[last:])
+
+	return res.String()
+}
+
+// node is a node of the radix tree. Each node is matched to a one segment of the path.
+type node[C, A any] struct {
+	segment segment
+	static  map[string]*node[C, A]
+	keys    []string // sorted keys of the static children
+	dynamic []*node[C, A]
+	route   *route[C, A]
+}
+
+func (n *node[C, A]) child(s segment) *node[C, A] {
+	if s.kind == segmentStatic {
+		if n.static == nil {
+			n.static = make(map[string]*node[C, A])
+		}
+
+		child, ok := n.static[s.key]
+		if !ok {
+			child = &node[C, A]{segment: s}
+			n.static[s.key] = child
+
+			n.keys = append(n.keys, s.key)
+			sort.Strings(n.keys)
+		}
+
+		return child
+	}
+
+	for _, child := range n.dynamic {
+		if child.segment.kind == s.kind && child.segment.key == s.key {
+			return child
+		}
+	}
+
+	child := &node[C, A]{segment: s}
+	n.dynamic = append(n.dynamic, child)
+
+	// the more specific segments are matched first
+	sort.SliceStable(n.dynamic, func(i, j int) bool {
+		return n.dynamic[i].segment.kind < n.dynamic[j].segment.kind
+	})
+
+	return child
+}
+
+// insert adds the route in to the tree and returns a node for it
+func (n *node[C, A]) insert(segments []segment) *node[C, A] {
+	current := n
+	for _, s := range segments {
+		current = current.child(s)
+	}
+
+	return current
+}
+
+// ambiguous reports routes under the sibling segments with patterns which can match the same value because
+// only routes of the first added segment will be used. The host is added to paths of the reported routes.
+func (n *node[C, A]) ambiguous(host string, errs *RouteErrors) {
+	for i, a := range n.dynamic {
+		for _, b := range n.dynamic[i+1:] {
+			if a.segment.kind != segmentPattern || b.segment.kind != segmentPattern || !a.segment.overlaps(b.segment) {
+				continue
+			}
+
+			others := strings.Join(a.paths(host, nil, map[*route[C, A]]bool{}), ", ")
+
+			for _, p := range b.paths(host, nil, map[*route[C, A]]bool{}) {
+				*errs = append(*errs, &RouteError{
+					Path: p,
+					Err:  fmt.Errorf("path segment [%s] is ambiguous with [%s]", b.segment.raw, others),
+				})
+			}
+		}
+	}
+
+	for _, k := range n.keys {
+		n.static[k].ambiguous(host, errs)
+	}
+
+	for _, child := range n.dynamic {
+		child.ambiguous(host, errs)
+	}
+}
+
+// paths returns paths of all routes under the node, mounted routes are placed in two nodes and are returned once
+func (n *node[C, A]) paths(host string, res []string, seen map[*route[C, A]]bool) []string {
+	if n.route != nil && !seen[n.route] {
+		seen[n.route] = true
+		res = append(res, host+n.route.path)
+	}
+
+	for _, k := range n.keys {
+		res = n.static[k].paths(host, res, seen)
+	}
+
+	for _, child := range n.dynamic {
+		res = child.paths(host, res, seen)
+	}
+
+	return res
+}
+
+// lookup finds a route for the path. The path must be passed without a leading slash.
+// If ci is true static segments are compared ignoring case.
+func (n *node[C, A]) lookup(path string, args []string, ci bool) (*route[C, A], []string) {
+	value, rest, more := strings.Cut(path, "/")
+
+	if child, ok := n.static[value]; ok {
+		if rt, res := child.next(rest, more, args, ci); rt != nil {
+			return rt, res
+		}
+	}
+
+	// keys are iterated in the sorted order so the same route is matched if several keys differ only by case
+	if ci {
+		for _, key := range n.keys {
+			if key == value || !strings.EqualFold(key, value) {
+				continue
+			}
+
+			if rt, res := n.static[key].next(rest, more, args, ci); rt != nil {
+				return rt, res
+			}
+		}
+	}
+
+	for _, child := range n.dynamic {
+		if child.segment.kind == segmentWildcard {
+			if child.route != nil {
+				return child.route, append(args, path)
+			}
+
+			continue
+		}
+
+		res, ok := child.segment.match(value, args)
+		if !ok {
+			continue
+		}
+
+		if rt, res := child.next(rest, more, res, ci); rt != nil {
+			return rt, res
+		}
+
+		args = res[:len(args)]
+	}
+
+	return nil, nil
+}
+
+func (n *node[C, A]) next(rest string, more bool, args []string, ci bool) (*route[C, A], []string) {
+	if !more {
+		if n.route == nil {
+			return nil, nil
+		}
+
+		return n.route, args
+	}
+
+	return n.lookup(rest, args, ci)
+}
@@ -0,0 +1,498 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Codes of the validation errors are the names of the rules of the validate tag
+const (
+	RuleRequired = "required"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RuleLen      = "len"
+	RulePattern  = "pattern"
+	RuleOneOf    = "oneof"
+	RuleEmail    = "email"
+	RuleUUID     = "uuid"
+
+	// ruleDive applies the next rules to the elements of the slice
+	ruleDive = "dive"
+)
+
+var uuidRegexp = regexp.MustCompile("^" + constraints["uuid"] + "$")
+
+// rule is a one constraint of the validate tag like min=1
+type rule struct {
+	name    string
+	param   string
+	num     float64
+	values  []string
+	pattern *regexp.Regexp
+}
+
+// parseRules parses the validate tag. The pattern rule must be the last one because the pattern can contain commas.
+// Rules after the dive are applied to the elements of the slice.
+func parseRules(tag string) ([]rule, []rule, error) {
+	var (
+		rules, elem []rule
+		dive        bool
+	)
+
+	for tag != "" {
+		var part string
+
+		if strings.HasPrefix(tag, RulePattern+"=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		r := rule{name: name, param: param}
+
+		switch name {
+		case ruleDive:
+			if dive {
+				return nil, nil, fmt.Errorf("rule [%s] is used twice", ruleDive)
+			}
+
+			dive = true
+
+			continue
+		case RuleRequired, RuleEmail, RuleUUID:
+		case RuleMin, RuleMax, RuleLen:
+			num, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("rule [%s] must have a number: %w", name, err)
+			}
+
+			r.num = num
+		case RulePattern:
+			reg, err := regexp.Compile(param)
+			if err != nil {
+				return nil, nil, fmt.Errorf("rule [%s] must have a correct regexp: %w", name, err)
+			}
+
+			r.pattern = reg
+		case RuleOneOf:
+			r.values = strings.Fields(param)
+
+			if len(r.values) == 0 {
+				return nil, nil, fmt.Errorf("rule [%s] must have values", name)
+			}
+		default:
+			return nil, nil, fmt.Errorf("unknown rule [%s]", name)
+		}
+
+		if dive {
+			elem = append(elem, r)
+		} else {
+			rules = append(rules, r)
+		}
+	}
+
+	if dive && len(elem) == 0 {
+		return nil, nil, fmt.Errorf("rule [%s] must be followed by rules for elements", ruleDive)
+	}
+
+	return rules, elem, nil
+}
+
+// supported checks that the rule can be applied to the values of the type
+func (r rule) supported(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	k := t.Kind()
+
+	switch r.name {
+	case RuleRequired:
+		return true
+	case RuleMin, RuleMax:
+		return isNumber(k) || k == reflect.String || k == reflect.Slice || k == reflect.Array || k == reflect.Map
+	case RuleLen:
+		return k == reflect.String || k == reflect.Slice || k == reflect.Array || k == reflect.Map
+	case RuleOneOf:
+		return isNumber(k) || k == reflect.String
+	default:
+		return k == reflect.String
+	}
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isEmpty returns true for zero values and empty slices and maps
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// isOmitted returns true for nil pointers, empty strings, slices and maps which are checked only by the required rule
+func isOmitted(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+// check returns the error message if the value does not match the rule
+func (r rule) check(v reflect.Value) string {
+	if r.name == RuleRequired {
+		if isEmpty(v) {
+			return "value is required"
+		}
+
+		return ""
+	}
+
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	var (
+		num    float64
+		length = true
+	)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, length = float64(v.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, length = float64(v.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		num, length = v.Float(), false
+	case reflect.String:
+		num = float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Array, reflect.Map:
+		num = float64(v.Len())
+	}
+
+	subject := "value"
+	if length {
+		subject = "length"
+	}
+
+	switch r.name {
+	case RuleMin:
+		if num < r.num {
+			return fmt.Sprintf("%s must be at least %s", subject, r.param)
+		}
+	case RuleMax:
+		if num > r.num {
+			return fmt.Sprintf("%s must be at most %s", subject, r.param)
+		}
+	case RuleLen:
+		if num != r.num {
+			return fmt.Sprintf("length must be %s", r.param)
+		}
+	case RulePattern:
+		if !r.pattern.MatchString(v.String()) {
+			return fmt.Sprintf("value must match pattern [%s]", r.param)
+		}
+	case RuleOneOf:
+		value := fmt.Sprint(v.Interface())
+
+		for _, allowed := range r.values {
+			if value == allowed {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf("value must be one of [%s]", strings.Join(r.values, ", "))
+	case RuleEmail:
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "value must be an email"
+		}
+	case RuleUUID:
+		if !uuidRegexp.MatchString(v.String()) {
+			return "value must be a UUID"
+		}
+	}
+
+	return ""
+}
+
+// fieldRules contains rules of the one field of the struct
+type fieldRules struct {
+	index    int
+	name     string
+	location string
+	rules    []rule
+	elem     []rule
+	nested   *structRules
+}
+
+// structRules contains rules for all fields of the struct and its nested structs
+type structRules struct {
+	fields []fieldRules
+}
+
+// fieldName returns the name and the location of the field like it is bound from the request
+func fieldName(f reflect.StructField) (string, string) {
+	for _, l := range [...]struct{ tag, location string }{
+		{"header", LocationHeader},
+		{"query", LocationQuery},
+		{"args", LocationArgs},
+		{"form", LocationForm},
+		{"file", LocationForm},
+	} {
+		if name := tagName(f.Tag.Get(l.tag)); name != "" && name != "-" {
+			return name, l.location
+		}
+	}
+
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, ""
+}
+
+// nestedStruct returns the struct type which fields are validated recursively
+func nestedStruct(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return t
+}
+
+// newStructRules parses the validate tags of the struct. It returns nil if the type is not a struct.
+func newStructRules(t reflect.Type) (*structRules, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	return buildStructRules(t, map[reflect.Type]*structRules{})
+}
+
+func buildStructRules(t reflect.Type, known map[reflect.Type]*structRules) (*structRules, error) {
+	if sr, ok := known[t]; ok {
+		return sr, nil
+	}
+
+	sr := &structRules{}
+	known[t] = sr
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if !f.IsExported() || f.Tag.Get("json") == "-" {
+			continue
+		}
+
+		fr := fieldRules{index: i}
+		fr.name, fr.location = fieldName(f)
+
+		rules, elem, err := parseRules(f.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("field [%s.%s]: %w", t.Name(), f.Name, err)
+		}
+
+		et := f.Type
+		for et.Kind() == reflect.Pointer {
+			et = et.Elem()
+		}
+
+		if len(elem) != 0 && et.Kind() != reflect.Slice && et.Kind() != reflect.Array {
+			return nil, fmt.Errorf("field [%s.%s]: rule [%s] can be used only for slices", t.Name(), f.Name, ruleDive)
+		}
+
+		for _, r := range rules {
+			if !r.supported(f.Type) {
+				return nil, fmt.Errorf("field [%s.%s]: rule [%s] is not supported for type %s", t.Name(), f.Name, r.name, f.Type)
+			}
+		}
+
+		for _, r := range elem {
+			if !r.supported(et.Elem()) {
+				return nil, fmt.Errorf("field [%s.%s]: rule [%s] is not supported for type %s", t.Name(), f.Name, r.name, et.Elem())
+			}
+		}
+
+		if _, format, _ := strings.Cut(f.Tag.Get("query"), ","); !knownCollection(format) {
+			return nil, fmt.Errorf("field [%s.%s]: unknown collection format [%s]", t.Name(), f.Name, format)
+		}
+
+		if f.Tag.Get("file") != "" && !isFileType(f.Type) {
+			return nil, fmt.Errorf("field [%s.%s]: type %s is not supported for files", t.Name(), f.Name, f.Type)
+		}
+
+		fr.rules, fr.elem = rules, elem
+
+		if nt := nestedStruct(f.Type); nt != nil {
+			fr.nested, err = buildStructRules(nt, known)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		sr.fields = append(sr.fields, fr)
+	}
+
+	return sr, nil
+}
+
+// validate checks the values of the struct and returns errors for all incorrect fields
+func (sr *structRules) validate(v reflect.Value, prefix, location string) []*FieldError {
+	if sr == nil {
+		return nil
+	}
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	var errs []*FieldError
+
+	for _, f := range sr.fields {
+		name, loc := prefix+f.name, location
+
+		if f.location != "" {
+			name, loc = f.name, f.location
+		}
+
+		if loc == "" {
+			loc = LocationBody
+		}
+
+		fv := v.Field(f.index)
+
+		errs = append(errs, checkRules(f.rules, fv, name, loc)...)
+
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+
+		switch fv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < fv.Len(); i++ {
+				elemName := fmt.Sprintf("%s[%d]", name, i)
+
+				errs = append(errs, checkRules(f.elem, fv.Index(i), elemName, loc)...)
+				errs = append(errs, f.nested.validate(fv.Index(i), elemName+".", loc)...)
+			}
+		case reflect.Struct:
+			errs = append(errs, f.nested.validate(fv, name+".", loc)...)
+		}
+	}
+
+	return errs
+}
+
+func checkRules(rules []rule, v reflect.Value, name, location string) []*FieldError {
+	var errs []*FieldError
+
+	for _, r := range rules {
+		if r.name != RuleRequired && isOmitted(v) {
+			continue
+		}
+
+		if msg := r.check(v); msg != "" {
+			errs = append(errs, &FieldError{
+				Field:    name,
+				Location: location,
+				Code:     r.name,
+				Message:  msg,
+			})
+		}
+	}
+
+	return errs
+}
+
+// applyRules describes the rules in the swagger schema
+func applyRules(t *apiType, rules []rule) {
+	for _, r := range rules {
+		num := r.num
+		length := int(r.num)
+
+		switch r.name {
+		case RuleRequired:
+			t.Required = true
+		case RuleMin:
+			switch t.Type {
+			case TypeString:
+				t.MinLength = &length
+			case TypeArray:
+				t.MinItems = &length
+			default:
+				t.Minimum = &num
+			}
+		case RuleMax:
+			switch t.Type {
+			case TypeString:
+				t.MaxLength = &length
+			case TypeArray:
+				t.MaxItems = &length
+			default:
+				t.Maximum = &num
+			}
+		case RuleLen:
+			if t.Type == TypeArray {
+				t.MinItems, t.MaxItems = &length, &length
+			} else {
+				t.MinLength, t.MaxLength = &length, &length
+			}
+		case RulePattern:
+			t.Pattern = r.param
+		case RuleOneOf:
+			t.Enum = make([]interface{}, 0, len(r.values))
+
+			for _, v := range r.values {
+				if n, err := strconv.ParseFloat(v, 64); err == nil && t.Type != TypeString {
+					t.Enum = append(t.Enum, n)
+				} else {
+					t.Enum = append(t.Enum, v)
+				}
+			}
+		case RuleEmail:
+			t.Format = "email"
+		case RuleUUID:
+			t.Format = "uuid"
+		}
+	}
+}
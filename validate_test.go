@@ -0,0 +1,264 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"len=5,pattern=^[0-9]+$"`
+}
+
+type validateRequest struct {
+	ID      string             `args:"id" validate:"uuid"`
+	Limit   int                `query:"limit" validate:"min=1,max=100"`
+	Name    string             `json:"name" validate:"required,min=2,max=10"`
+	Email   string             `json:"email,omitempty" validate:"email"`
+	Role    string             `json:"role" validate:"oneof=admin user"`
+	Tags    []string           `json:"tags" validate:"max=2,dive,min=3"`
+	Address *validateAddress   `json:"address"`
+	Other   []*validateAddress `json:"other"`
+}
+
+type validateResponse struct {
+	Next *validateResponse `json:"next"`
+}
+
+func TestValidate(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/user/{id}", handler{
+		Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *validateRequest) (*TestResponse, error) {
+			return &TestResponse{Data: r.Name}, nil
+		}),
+	})
+
+	h := NewHttpHandler(router, Options{})
+
+	do := func(path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := do("/user/0b5bd3f4-2b5c-4bd1-9d0e-3f1d1b0a6c9e?limit=10", `{"name":"bob","email":"bob@example.com","role":"admin","tags":["red"],"address":{"city":"Oslo","zip":"01234"}}`)
+	assert(t, w.Code, http.StatusOK)
+	assert(t, w.Body.String(), "{\"data\":\"bob\"}\n")
+
+	w = do("/user/1?limit=0", `{"email":"bob","role":"root","tags":["a","green","blue"],"address":{"zip":"1"},"other":[{"city":"Oslo","zip":"abcde"}]}`)
+	assert(t, w.Code, http.StatusBadRequest)
+
+	var resp struct {
+		Errors []*FieldError `json:"errors"`
+	}
+
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		res = append(res, e.Location+" "+e.Field+" "+e.Code+": "+e.Message)
+	}
+
+	assert(t, res, []string{
+		"args id uuid: value must be a UUID",
+		"query limit min: value must be at least 1",
+		"body name required: value is required",
+		"body email email: value must be an email",
+		"body role oneof: value must be one of [admin, user]",
+		"body tags max: length must be at most 2",
+		"body tags[0] min: length must be at least 3",
+		"body address.city required: value is required",
+		"body address.zip len: length must be 5",
+		"body other[0].zip pattern: value must match pattern [^[0-9]+$]",
+	})
+
+	w = do("/user/0b5bd3f4-2b5c-4bd1-9d0e-3f1d1b0a6c9e?limit=10", `{bad`)
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"location":"body","code":"malformed","message":"invalid character 'b' looking for beginning of object key string"}]}`+"\n")
+
+	w = do("/user/1?limit=a", `{"name":"bob"}`)
+	assert(t, w.Body.String(), `{"code":400,"error":"incorrect request","errors":[{"field":"limit","location":"query","code":"invalid","message":"value [a] must be int"},{"field":"id","location":"args","code":"uuid","message":"value must be a UUID"}]}`+"\n")
+}
+
+func TestValidateRules(t *testing.T) {
+	for tag, expected := range map[string]string{
+		"required,min=1":          "",
+		"min=a":                   "rule [min] must have a number: strconv.ParseFloat: parsing \"a\": invalid syntax",
+		"pattern=[a-z":            "rule [pattern] must have a correct regexp: error parsing regexp: missing closing ]: `[a-z`",
+		"oneof=":                  "rule [oneof] must have values",
+		"unknown":                 "unknown rule [unknown]",
+		"dive":                    "rule [dive] must be followed by rules for elements",
+		"dive,required,dive,uuid": "rule [dive] is used twice",
+	} {
+		_, _, err := parseRules(tag)
+
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+
+		assert(t, msg, expected)
+	}
+
+	_, err := newStructRules(reflect.TypeOf(struct {
+		Count int `json:"count" validate:"email"`
+	}{}))
+
+	assert(t, err.Error(), "field [.Count]: rule [email] is not supported for type int")
+
+	_, err = newStructRules(reflect.TypeOf(struct {
+		Name string `json:"name" validate:"dive,min=1"`
+	}{}))
+
+	assert(t, err.Error(), "field [.Name]: rule [dive] can be used only for slices")
+}
+
+func TestValidateSwagger(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/user/{id}", handler{
+		Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *validateRequest) (*validateResponse, error) {
+			return nil, nil
+		}),
+	})
+
+	router.Add("/name", handler{
+		Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) (string, error) {
+			return "", nil
+		}),
+	})
+
+	router.Add("/addresses", handler{
+		Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *TestRequest) ([]validateAddress, error) {
+			return nil, nil
+		}),
+	})
+
+	router.AddSwagger("/swagger.json", SwaggerOpt{})
+
+	w := httptest.NewRecorder()
+	NewHttpHandler(router, Options{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	data := w.Body.String()
+
+	for _, s := range []string{
+		`{"in":"path","name":"id","type":"string","required":true,"format":"uuid"}`,
+		`{"in":"query","name":"limit","type":"integer","format":"int64","minimum":1,"maximum":100}`,
+		`"name":{"type":"string","minLength":2,"maxLength":10}`,
+		`"email":{"type":"string","format":"email"}`,
+		`"role":{"type":"string","enum":["admin","user"]}`,
+		`"tags":{"type":"array","maxItems":2,"items":{"type":"string","minLength":3}}`,
+		`"zip":{"type":"string","pattern":"^[0-9]+$","minLength":5,"maxLength":5}},"required":["city"]}`,
+		`"required":["name"]`,
+		`"validateResponse":{"type":"object","properties":{"next":{"type":"object"}}}`,
+		`"200":{"description":"Success response","schema":{"type":"string"}}`,
+		`"200":{"description":"Success response","schema":{"type":"array","items":{"type":"object","properties":{"city"`,
+	} {
+		if !strings.Contains(data, s) {
+			t.Errorf("swagger does not contain %s", s)
+		}
+	}
+
+	if strings.Contains(data, `"#/definitions/string"`) {
+		t.Error("swagger contains the definition of string")
+	}
+}
+
+type periodRequest struct {
+	Start int `query:"start"`
+	End   int `query:"end"`
+}
+
+func (r *periodRequest) Validate(ctx context.Context) error {
+	if r.End < r.Start {
+		return errors.New("end must be after start")
+	}
+
+	if r.Start < 0 {
+		return NewError(http.StatusConflict, "start is in the past")
+	}
+
+	return nil
+}
+
+type skuRequest struct {
+	SKU string `args:"sku"`
+}
+
+func (r *skuRequest) Validate(ctx context.Context, c *TestContainer) error {
+	if r.SKU != c.data {
+		return fmt.Errorf("sku [%s] does not exist", r.SKU)
+	}
+
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	router := NewRouter[*TestContainer, *TestUserData]()
+
+	router.Add("/period", handler{
+		Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *periodRequest) (*TestResponse, error) {
+			return &TestResponse{Data: "ok"}, nil
+		}),
+		Post: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *periodRequest) (*TestResponse, error) {
+			return &TestResponse{Data: "ok"}, nil
+		}, ValidationErrorCode(http.StatusUnprocessableEntity)),
+	})
+
+	router.Add("/sku/{sku}", handler{
+		Get: Create(func(ctx context.Context, c *TestContainer, u *TestUserData, r *skuRequest) (*TestResponse, error) {
+			return &TestResponse{Data: r.SKU}, nil
+		}),
+	})
+
+	h := NewHttpHandler(router, Options{}).SetContainer(&TestContainer{data: "abc"})
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+
+		return w
+	}
+
+	w := do(http.MethodGet, "/period?start=1&end=2")
+	assert(t, w.Code, http.StatusOK)
+
+	w = do(http.MethodGet, "/period?start=2&end=1")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"end must be after start"}`+"\n")
+
+	w = do(http.MethodPost, "/period?start=2&end=1")
+	assert(t, w.Code, http.StatusUnprocessableEntity)
+
+	err := callValidator(context.Background(), &TestContainer{}, &periodRequest{Start: 2, End: 1}, http.StatusBadRequest)
+	assert(t, err.Error(), "http error [400] end must be after start")
+
+	w = do(http.MethodPost, "/period?start=-2&end=1")
+	assert(t, w.Code, http.StatusConflict)
+	assert(t, w.Body.String(), `{"code":409,"error":"start is in the past"}`+"\n")
+
+	w = do(http.MethodGet, "/period?start=a")
+	assert(t, w.Code, http.StatusBadRequest)
+
+	w = do(http.MethodGet, "/sku/abc")
+	assert(t, w.Body.String(), "{\"data\":\"abc\"}\n")
+
+	w = do(http.MethodGet, "/sku/xyz")
+	assert(t, w.Code, http.StatusBadRequest)
+	assert(t, w.Body.String(), `{"code":400,"error":"sku [xyz] does not exist"}`+"\n")
+}
@@ -0,0 +1,158 @@
+package httpserver
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// VersionOpt is options of the API version negotiation. The version is used only for routes
+// which were added by AddVersion.
+type VersionOpt struct {
+	// Header is a name of the header which contains the version, for example API-Version
+	Header string
+
+	// Vendor enables the version negotiation by media type in Accept header. For vendor acme
+	// the version is taken from application/vnd.acme.v2+json or application/vnd.acme+json; version=v2
+	Vendor string
+
+	// Default is a version which is used if the version is not requested
+	Default string
+}
+
+// Versioning sets options of the API version negotiation
+func (r *Router[C, A]) Versioning(opt VersionOpt) *Router[C, A] {
+	r.versionOpt = opt
+
+	return r
+}
+
+// AddVersion adds the handler for the version of the route. Versions and the handler
+// without version can be added for the same path.
+func (r *Router[C, A]) AddVersion(path, version string, h Handler[C, A]) *Router[C, A] {
+	err := r.add(path, version, h, nil)
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
+
+	return r
+}
+
+// AddVersion adds the handler for the version of the route in the sub router
+func (r *SubRouter[C, A]) AddVersion(subPath, version string, h Handler[C, A]) *SubRouter[C, A] {
+	err := r.r.add(path.Join(r.subPath, subPath), version, h, r)
+	if err != nil {
+		r.r.errs = append(r.r.errs, err)
+	}
+
+	return r
+}
+
+func (rt *route[C, A]) addVersion(version string, h Handler[C, A]) error {
+	if version == "" {
+		if rt.base {
+			return fmt.Errorf("duplicate route")
+		}
+
+		rt.handler = h
+		rt.base = true
+
+		return nil
+	}
+
+	if _, ok := rt.version(version); ok {
+		return fmt.Errorf("duplicate version [%s]", version)
+	}
+
+	rt.versions.Add(version, h)
+
+	return nil
+}
+
+func (rt *route[C, A]) version(version string) (*Handler[C, A], bool) {
+	for i, v := range rt.versions {
+		if v.name == version {
+			return &rt.versions[i].value, true
+		}
+	}
+
+	return nil, false
+}
+
+// acceptVersion returns the version from the vendor media type of the Accept header
+func acceptVersion(accept, vendor string) string {
+	prefix := "application/vnd." + vendor
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.HasPrefix(mediaType, prefix) {
+			continue
+		}
+
+		// the vendor name must end at the boundary, e.g. acme does not match acmecorp
+		rest := strings.TrimPrefix(mediaType, prefix)
+		if rest != "" && rest[0] != '.' && rest[0] != '+' {
+			continue
+		}
+
+		if v := params["version"]; v != "" {
+			return v
+		}
+
+		if rest == "" || rest[0] != '.' {
+			continue
+		}
+
+		v, _, _ := strings.Cut(rest[1:], "+")
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// endpoint returns the handler of the route for the requested version
+func (r *Router[C, A]) endpoint(rt *route[C, A], w http.ResponseWriter, req *http.Request) (*Handler[C, A], error) {
+	if len(rt.versions) == 0 {
+		return &rt.handler, nil
+	}
+
+	opt := r.versionOpt
+
+	if opt.Header != "" {
+		w.Header().Add("Vary", opt.Header)
+
+		if v := req.Header.Get(opt.Header); v != "" {
+			if h, ok := rt.version(v); ok {
+				return h, nil
+			}
+
+			return nil, NewError(http.StatusBadRequest, "unknown API version [%s]", v)
+		}
+	}
+
+	if opt.Vendor != "" {
+		w.Header().Add("Vary", "Accept")
+
+		if v := acceptVersion(req.Header.Get("Accept"), opt.Vendor); v != "" {
+			if h, ok := rt.version(v); ok {
+				return h, nil
+			}
+
+			return nil, NewError(http.StatusNotAcceptable, "unknown API version [%s]", v)
+		}
+	}
+
+	if h, ok := rt.version(opt.Default); ok {
+		return h, nil
+	}
+
+	if rt.base {
+		return &rt.handler, nil
+	}
+
+	return nil, NewError(http.StatusBadRequest, "API version is required")
+}